@@ -0,0 +1,98 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Algorithm selects the strategy used to match lines between two files
+type Algorithm int
+
+const (
+	AlgorithmMyers     Algorithm = iota // minimal edit script
+	AlgorithmPatience                   // anchors on lines unique to both sides
+	AlgorithmHistogram                  // anchors on the least frequent common lines
+)
+
+// Algorithms lists every supported algorithm in cycling order
+var Algorithms = []Algorithm{AlgorithmMyers, AlgorithmPatience, AlgorithmHistogram}
+
+// String returns the name used on the command line and in the UI
+func (a Algorithm) String() string {
+	switch a {
+	case AlgorithmPatience:
+		return "patience"
+	case AlgorithmHistogram:
+		return "histogram"
+	default:
+		return "myers"
+	}
+}
+
+// Next returns the algorithm that follows a in cycling order
+func (a Algorithm) Next() Algorithm {
+	for i, alg := range Algorithms {
+		if alg == a {
+			return Algorithms[(i+1)%len(Algorithms)]
+		}
+	}
+	return AlgorithmMyers
+}
+
+// ParseAlgorithm converts a name such as "patience" into an Algorithm
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "myers", "minimal", "default":
+		return AlgorithmMyers, nil
+	case "patience":
+		return AlgorithmPatience, nil
+	case "histogram":
+		return AlgorithmHistogram, nil
+	}
+	return AlgorithmMyers, fmt.Errorf("unknown diff algorithm %q (want myers, patience or histogram)", name)
+}
+
+// matchLines returns the matched (leftIndex, rightIndex) pairs between a
+// and b using the given algorithm, within the work allowed by bud.
+func matchLines(alg Algorithm, a, b []int, bud *budget) [][2]int {
+	switch alg {
+	case AlgorithmPatience:
+		return patienceMatches(a, b, bud)
+	case AlgorithmHistogram:
+		return histogramMatches(a, b, bud)
+	default:
+		return budgetedMyers(a, b, bud)
+	}
+}
+
+// myersRange runs Myers on a sub-range and appends the matches with their
+// absolute indexes. Patience and histogram fall back to it when they find
+// no anchors.
+func myersRange(a, b []int, aLo, aHi, bLo, bHi int, bud *budget, out *[][2]int) {
+	for _, mt := range budgetedMyers(a[aLo:aHi], b[bLo:bHi], bud) {
+		*out = append(*out, [2]int{aLo + mt[0], bLo + mt[1]})
+	}
+}
+
+// trimCommon appends matches for the common prefix of the range and returns
+// the narrowed range together with the length of the common suffix, which
+// the caller must emit after the middle.
+func trimCommon(a, b []int, aLo, aHi, bLo, bHi int, out *[][2]int) (int, int, int, int, int) {
+	for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+		*out = append(*out, [2]int{aLo, bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-suffix-1] == b[bHi-suffix-1] {
+		suffix++
+	}
+	return aLo, aHi - suffix, bLo, bHi - suffix, suffix
+}
+
+// appendSuffix emits the matches for a common suffix found by trimCommon
+func appendSuffix(aHi, bHi, suffix int, out *[][2]int) {
+	for i := 0; i < suffix; i++ {
+		*out = append(*out, [2]int{aHi + i, bHi + i})
+	}
+}
@@ -0,0 +1,118 @@
+package differ
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// ---- ParseAlgorithm ---------------------------------------------------------
+
+func TestParseAlgorithm(t *testing.T) {
+	cases := map[string]Algorithm{
+		"myers":     AlgorithmMyers,
+		"minimal":   AlgorithmMyers,
+		"Patience":  AlgorithmPatience,
+		"histogram": AlgorithmHistogram,
+	}
+	for name, want := range cases {
+		got, err := ParseAlgorithm(name)
+		if err != nil {
+			t.Errorf("ParseAlgorithm(%q) error: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("ParseAlgorithm(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := ParseAlgorithm("bogus"); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
+
+func TestAlgorithmNextCycles(t *testing.T) {
+	alg := AlgorithmMyers
+	for range Algorithms {
+		alg = alg.Next()
+	}
+	if alg != AlgorithmMyers {
+		t.Errorf("cycling through all algorithms should return to myers, got %v", alg)
+	}
+}
+
+// ---- valid matches ----------------------------------------------------------
+
+func TestAllAlgorithmsProduceValidMatches(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, alg := range Algorithms {
+		for iter := 0; iter < 300; iter++ {
+			a := randomSeq(r, r.Intn(40), 1+r.Intn(8))
+			b := randomSeq(r, r.Intn(40), 1+r.Intn(8))
+			checkMatches(t, a, b, matchLines(alg, a, b, nil))
+		}
+	}
+}
+
+func TestAllAlgorithmsReconstructBothSides(t *testing.T) {
+	left := "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n"
+	right := "func a() {\n\treturn 1\n}\n\nfunc c() {\n\treturn 3\n}\n\nfunc b() {\n\treturn 2\n}\n"
+	for _, alg := range Algorithms {
+		diff := NewWithOptions(Options{Algorithm: alg}).CompareStrings("l", "r", left, right)
+
+		var gotLeft, gotRight []string
+		for _, line := range diff.Lines {
+			if line.Type != DiffInsert {
+				gotLeft = append(gotLeft, line.Content)
+			}
+			if line.Type != DiffDelete {
+				gotRight = append(gotRight, line.Content)
+			}
+		}
+		if strings.Join(gotLeft, "\n")+"\n" != left {
+			t.Errorf("%v: left side not reconstructed: %q", alg, gotLeft)
+		}
+		if strings.Join(gotRight, "\n")+"\n" != right {
+			t.Errorf("%v: right side not reconstructed: %q", alg, gotRight)
+		}
+	}
+}
+
+// ---- readability ------------------------------------------------------------
+
+func TestPatienceKeepsFunctionsTogether(t *testing.T) {
+	// Inserting a whole function between two others should show up as one
+	// contiguous insertion, not braces interleaved with the old code.
+	left := "func a() {\n\tx()\n}\n\nfunc b() {\n\ty()\n}\n"
+	right := "func a() {\n\tx()\n}\n\nfunc n() {\n\tz()\n}\n\nfunc b() {\n\ty()\n}\n"
+
+	for _, alg := range []Algorithm{AlgorithmPatience, AlgorithmHistogram} {
+		diff := NewWithOptions(Options{Algorithm: alg}).CompareStrings("l", "r", left, right)
+		runs := 0
+		inRun := false
+		for _, line := range diff.Lines {
+			if line.Type == DiffInsert {
+				if !inRun {
+					runs++
+				}
+				inRun = true
+			} else {
+				inRun = false
+			}
+		}
+		if runs != 1 {
+			t.Errorf("%v: expected a single contiguous insertion, got %d runs", alg, runs)
+		}
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	pairs := [][2]int{{3, 0}, {1, 1}, {4, 2}, {2, 3}, {5, 4}}
+	got := longestIncreasing(pairs)
+	if len(got) != 3 {
+		t.Fatalf("expected run of 3, got %v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i][0] <= got[i-1][0] || got[i][1] <= got[i-1][1] {
+			t.Errorf("run not increasing: %v", got)
+		}
+	}
+}
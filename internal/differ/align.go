@@ -0,0 +1,164 @@
+package differ
+
+import "sort"
+
+const (
+	// maxAlignCells bounds the deletions × insertions a change block may
+	// have before it is paired by position instead of by similarity
+	maxAlignCells = 1 << 16
+	// pairBonus is added to every pair's similarity so that, all else being
+	// equal, more lines are paired rather than left on rows of their own
+	pairBonus = 0.01
+	// minPairSimilarity is how alike a deleted and an inserted line must be
+	// to be paired; less alike ones stay a plain deletion and insertion
+	minPairSimilarity = 0.5
+)
+
+// pairChanges pairs the deleted and inserted lines of every change block so
+// each deletion sits beside the insertion it most resembles. Pairs keep the
+// order of both sides and maximise the total similarity of the block, the
+// way Meld and Beyond Compare line up rewritten paragraphs. A block of one
+// deleted and one inserted line is a replacement and always paired; in
+// larger blocks, lines less alike than minPairSimilarity are not. Ignored
+// and moved lines are never paired.
+func pairChanges(lines []DiffLine) {
+	for i := 0; i < len(lines); {
+		if lines[i].Type == DiffEqual {
+			i++
+			continue
+		}
+		end := changeBlockEnd(lines, i)
+
+		var dels, ins []int
+		for k := i; k < end; k++ {
+			switch {
+			case isPairable(lines[k], DiffDelete):
+				dels = append(dels, k)
+			case isPairable(lines[k], DiffInsert):
+				ins = append(ins, k)
+			}
+		}
+		for _, p := range alignBlock(lines, dels, ins) {
+			lines[p[0]].Pair = p[1] + 1
+			lines[p[1]].Pair = p[0] + 1
+		}
+		i = end
+	}
+}
+
+// changeBlockEnd returns the index just past the run of changed lines
+// starting at i
+func changeBlockEnd(lines []DiffLine, i int) int {
+	for i < len(lines) && lines[i].Type != DiffEqual {
+		i++
+	}
+	return i
+}
+
+func isPairable(line DiffLine, t DiffType) bool {
+	return line.Type == t && !line.Ignored && line.MoveID == 0
+}
+
+// alignBlock chooses which of the deleted lines dels pair with which of the
+// inserted lines ins, returning index pairs in order. It is a weighted
+// longest common subsequence with line similarity as the weight.
+func alignBlock(lines []DiffLine, dels, ins []int) [][2]int {
+	m, n := len(dels), len(ins)
+	if m == 0 || n == 0 {
+		return nil
+	}
+	if m == 1 && n == 1 {
+		return [][2]int{{dels[0], ins[0]}}
+	}
+	if m*n > maxAlignCells {
+		var pairs [][2]int
+		for k := 0; k < m && k < n; k++ {
+			if lineSimilarity(lines[dels[k]].Content, lines[ins[k]].Content) >= minPairSimilarity {
+				pairs = append(pairs, [2]int{dels[k], ins[k]})
+			}
+		}
+		return pairs
+	}
+
+	left := make([][]uint16, m)
+	for a, i := range dels {
+		left[a] = bigrams(lines[i].Content)
+	}
+	right := make([][]uint16, n)
+	for b, j := range ins {
+		right[b] = bigrams(lines[j].Content)
+	}
+
+	// score[a][b] is the best total for dels[:a] against ins[:b]
+	w := n + 1
+	score := make([]float64, (m+1)*w)
+	for a := 1; a <= m; a++ {
+		for b := 1; b <= n; b++ {
+			best := score[(a-1)*w+b]
+			if s := score[a*w+b-1]; s > best {
+				best = s
+			}
+			sim := bigramSimilarity(lines[dels[a-1]].Content, lines[ins[b-1]].Content, left[a-1], right[b-1])
+			if s := score[(a-1)*w+b-1] + sim + pairBonus; sim >= minPairSimilarity && s > best {
+				best = s
+			}
+			score[a*w+b] = best
+		}
+	}
+
+	var pairs [][2]int
+	for a, b := m, n; a > 0 && b > 0; {
+		switch score[a*w+b] {
+		case score[(a-1)*w+b]:
+			a--
+		case score[a*w+b-1]:
+			b--
+		default:
+			pairs = append(pairs, [2]int{dels[a-1], ins[b-1]})
+			a--
+			b--
+		}
+	}
+	for l, r := 0, len(pairs)-1; l < r; l, r = l+1, r-1 {
+		pairs[l], pairs[r] = pairs[r], pairs[l]
+	}
+	return pairs
+}
+
+// bigrams returns the sorted character bigrams of s
+func bigrams(s string) []uint16 {
+	if len(s) < 2 {
+		return nil
+	}
+	grams := make([]uint16, len(s)-1)
+	for i := range grams {
+		grams[i] = uint16(s[i])<<8 | uint16(s[i+1])
+	}
+	sort.Slice(grams, func(i, j int) bool { return grams[i] < grams[j] })
+	return grams
+}
+
+// bigramSimilarity is lineSimilarity for strings whose bigrams are already
+// known
+func bigramSimilarity(a, b string, ga, gb []uint16) float64 {
+	if a == b {
+		return 1
+	}
+	if len(ga) == 0 || len(gb) == 0 {
+		return 0
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(ga) && j < len(gb); {
+		switch {
+		case ga[i] < gb[j]:
+			i++
+		case ga[i] > gb[j]:
+			j++
+		default:
+			shared++
+			i++
+			j++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ga)+len(gb))
+}
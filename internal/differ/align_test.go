@@ -0,0 +1,98 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func rowTypes(rows []SideBySideRow) []SideBySideRowType {
+	types := make([]SideBySideRowType, len(rows))
+	for i, row := range rows {
+		types[i] = row.Type
+	}
+	return types
+}
+
+func TestRewrittenBlockPairsAsWhole(t *testing.T) {
+	left := "start\nthe quick brown fox\njumps over the lazy dog\nand runs away\nend\n"
+	right := "start\nthe quick red fox\njumps over the sleepy dog\nand runs off\nend\n"
+
+	rows := BuildSideBySideRows(New().CompareStrings("l", "r", left, right).Lines)
+	want := []SideBySideRowType{SBSEqual, SBSModified, SBSModified, SBSModified, SBSEqual}
+	if got := rowTypes(rows); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("row types = %v, want %v", got, want)
+	}
+	for _, row := range rows[1:4] {
+		if row.LeftLineNum != row.RightLineNum {
+			t.Errorf("rewritten line %d paired with %d", row.LeftLineNum, row.RightLineNum)
+		}
+		if len(row.LeftSpans) == 0 || len(row.RightSpans) == 0 {
+			t.Errorf("paired row should carry spans: %+v", row)
+		}
+	}
+}
+
+func TestBlockPairsMostSimilarLines(t *testing.T) {
+	left := "a\nconfig.port = 8080\nz\n"
+	right := "a\n// listen settings\nconfig.host = localhost\nconfig.port = 9090\nz\n"
+
+	rows := BuildSideBySideRows(New().CompareStrings("l", "r", left, right).Lines)
+	var modified []SideBySideRow
+	for _, row := range rows {
+		if row.Type == SBSModified {
+			modified = append(modified, row)
+		}
+	}
+	if len(modified) != 1 || modified[0].RightContent != "config.port = 9090" {
+		t.Fatalf("expected the port lines to pair, got %+v", modified)
+	}
+	want := []SideBySideRowType{SBSEqual, SBSInsert, SBSInsert, SBSModified, SBSEqual}
+	if got := rowTypes(rows); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("row types = %v, want %v", got, want)
+	}
+}
+
+func TestDissimilarLinesStayApart(t *testing.T) {
+	left := "a\nconfig.port = 8080\nreturn nil\nz\n"
+	right := "a\nconfig.port = 9090\nlog.Fatal(err)\nz\n"
+
+	rows := BuildSideBySideRows(New().CompareStrings("l", "r", left, right).Lines)
+	want := []SideBySideRowType{SBSEqual, SBSModified, SBSDelete, SBSInsert, SBSEqual}
+	if got := rowTypes(rows); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("row types = %v, want %v", got, want)
+	}
+
+	// A lone replacement pairs however little the lines have in common
+	rows = BuildSideBySideRows(New().CompareStrings("l", "r", "a\nold\nz\n", "a\nnew\nz\n").Lines)
+	if got := rowTypes(rows); fmt.Sprint(got) != fmt.Sprint([]SideBySideRowType{SBSEqual, SBSModified, SBSEqual}) {
+		t.Errorf("replacement row types = %v", got)
+	}
+}
+
+func TestPairsLinkBothWays(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "one\ntwo\nthree\n", "one!\ntwo!\nthree!\n")
+	for i, line := range diff.Lines {
+		if line.Pair == 0 {
+			t.Errorf("line %d %q should be paired", i, line.Content)
+			continue
+		}
+		if back := diff.Lines[line.Pair-1].Pair; back != i+1 {
+			t.Errorf("line %d pairs with %d, which points back at %d", i, line.Pair-1, back-1)
+		}
+	}
+}
+
+func TestLargeBlockPairsByPosition(t *testing.T) {
+	var left, right strings.Builder
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&left, "left line %d\n", i)
+		fmt.Fprintf(&right, "right line %d\n", i)
+	}
+	diff := New().CompareStrings("l", "r", left.String(), right.String())
+	for _, row := range BuildSideBySideRows(diff.Lines) {
+		if row.Type != SBSModified || row.LeftLineNum != row.RightLineNum {
+			t.Fatalf("large block should pair line for line, got %+v", row)
+		}
+	}
+}
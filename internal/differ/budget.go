@@ -0,0 +1,62 @@
+package differ
+
+import (
+	"context"
+	"time"
+)
+
+// budgetCheckInterval is how much work is done between checks of the
+// context and the clock
+const budgetCheckInterval = 1 << 12
+
+// budget limits the work spent matching lines. Once it runs out, Myers stops
+// searching for a minimal edit script and aligns the remaining ranges on
+// lines unique to both sides instead. A nil budget is unlimited.
+type budget struct {
+	ctx       context.Context
+	deadline  time.Time // zero for no time limit
+	maxCost   int       // zero for no cost limit
+	cost      int
+	nextCheck int
+	exhausted bool
+}
+
+func newBudget(ctx context.Context, opts Options) *budget {
+	b := &budget{ctx: ctx, maxCost: opts.MaxCost}
+	if opts.TimeBudget > 0 {
+		b.deadline = time.Now().Add(opts.TimeBudget)
+	}
+	return b
+}
+
+// spend records n units of work and reports whether more may be done
+func (b *budget) spend(n int) bool {
+	if b == nil {
+		return true
+	}
+	if b.exhausted {
+		return false
+	}
+	b.cost += n
+	if b.maxCost > 0 && b.cost > b.maxCost {
+		b.exhausted = true
+	} else if b.cost >= b.nextCheck {
+		b.nextCheck = b.cost + budgetCheckInterval
+		if b.ctx.Err() != nil || (!b.deadline.IsZero() && time.Now().After(b.deadline)) {
+			b.exhausted = true
+		}
+	}
+	return !b.exhausted
+}
+
+// cancelled reports whether the caller has given up on the result, in which
+// case not even the heuristic fallback is worth running
+func (b *budget) cancelled() bool {
+	return b != nil && b.ctx.Err() != nil
+}
+
+// approximate reports whether the budget ran out, so the matches found are
+// not guaranteed to be minimal
+func (b *budget) approximate() bool {
+	return b != nil && b.exhausted
+}
@@ -0,0 +1,86 @@
+package differ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// scattered returns two files of n lines that differ on every third line
+func scattered(n int) (string, string) {
+	var left, right strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&left, "line %d\n", i)
+		if i%3 == 0 {
+			fmt.Fprintf(&right, "changed %d\n", i)
+		} else {
+			fmt.Fprintf(&right, "line %d\n", i)
+		}
+	}
+	return left.String(), right.String()
+}
+
+// swapped returns two files of n lines where the right one swaps every
+// other pair of lines, so every line is common but out of order
+func swapped(n int) (string, string) {
+	var left, right strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&left, "line %d\n", i)
+		j := i
+		if i%4 < 2 {
+			j = i ^ 1
+		}
+		fmt.Fprintf(&right, "line %d\n", j)
+	}
+	return left.String(), right.String()
+}
+
+func TestCostBudgetFallsBack(t *testing.T) {
+	left, right := swapped(4000)
+	diff, err := NewWithOptions(Options{MaxCost: 1000}).CompareContext(context.Background(), "l", "r", left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Approximate {
+		t.Fatal("a diff over budget should be marked approximate")
+	}
+
+	var gotLeft, gotRight strings.Builder
+	for _, line := range diff.Lines {
+		if line.Type != DiffInsert {
+			gotLeft.WriteString(line.Content + "\n")
+		}
+		if line.Type != DiffDelete {
+			gotRight.WriteString(line.Content + "\n")
+		}
+	}
+	if gotLeft.String() != left || gotRight.String() != right {
+		t.Error("approximate diff does not rebuild the inputs")
+	}
+	if equal, _, _ := diff.GetStats(); equal < 3000 {
+		t.Errorf("the fallback should still align most unchanged lines, got %d equal", equal)
+	}
+}
+
+func TestWithinBudgetIsExact(t *testing.T) {
+	left, right := scattered(300)
+	diff := New().CompareStrings("l", "r", left, right)
+	if diff.Approximate {
+		t.Error("a small diff should not run out of budget")
+	}
+	if _, inserted, deleted := diff.GetStats(); inserted != 100 || deleted != 100 {
+		t.Errorf("stats = +%d -%d, want +100 -100", inserted, deleted)
+	}
+}
+
+func TestCompareContextCancelled(t *testing.T) {
+	left, right := scattered(3000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	diff, err := New().CompareContext(ctx, "l", "r", left, right)
+	if !errors.Is(err, context.Canceled) || diff != nil {
+		t.Errorf("cancelled diff returned %v, %v", diff, err)
+	}
+}
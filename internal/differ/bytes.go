@@ -0,0 +1,74 @@
+package differ
+
+import "context"
+
+// ByteCell is one aligned position of a byte-level diff: a byte from each
+// side, or -1 where that side has nothing to show against the other
+type ByteCell struct {
+	Left  int16
+	Right int16
+}
+
+// Changed reports whether the two sides differ at this position
+func (c ByteCell) Changed() bool {
+	return c.Left != c.Right
+}
+
+// ByteDiff aligns two binary files byte by byte for a hex view
+type ByteDiff struct {
+	Cells []ByteCell
+
+	// Approximate is set when the alignment hit the budget; see
+	// FileDiff.Approximate
+	Approximate bool
+}
+
+// CompareBytes aligns left and right so that bytes inserted or removed in
+// one file do not throw every following byte out of step. Within a changed
+// range the two sides are shown against each other position by position,
+// and the longer side's extra bytes are set against gaps. The algorithm and
+// budget options apply as for lines.
+func (d *Differ) CompareBytes(ctx context.Context, left, right []byte) (*ByteDiff, error) {
+	a := make([]int, len(left))
+	for i, c := range left {
+		a[i] = int(c)
+	}
+	b := make([]int, len(right))
+	for i, c := range right {
+		b[i] = int(c)
+	}
+
+	bud := newBudget(ctx, d.Options)
+	matches := matchLines(d.Algorithm, a, b, bud)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	diff := &ByteDiff{
+		Cells:       make([]ByteCell, 0, max(len(left), len(right))),
+		Approximate: bud.approximate(),
+	}
+	li, ri := 0, 0
+	gap := func(leftEnd, rightEnd int) {
+		for li < leftEnd || ri < rightEnd {
+			cell := ByteCell{Left: -1, Right: -1}
+			if li < leftEnd {
+				cell.Left = int16(left[li])
+				li++
+			}
+			if ri < rightEnd {
+				cell.Right = int16(right[ri])
+				ri++
+			}
+			diff.Cells = append(diff.Cells, cell)
+		}
+	}
+	for _, mt := range matches {
+		gap(mt[0], mt[1])
+		diff.Cells = append(diff.Cells, ByteCell{Left: int16(left[li]), Right: int16(right[ri])})
+		li++
+		ri++
+	}
+	gap(len(left), len(right))
+	return diff, nil
+}
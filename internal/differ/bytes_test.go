@@ -0,0 +1,60 @@
+package differ
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func sides(cells []ByteCell) ([]byte, []byte) {
+	var left, right []byte
+	for _, c := range cells {
+		if c.Left >= 0 {
+			left = append(left, byte(c.Left))
+		}
+		if c.Right >= 0 {
+			right = append(right, byte(c.Right))
+		}
+	}
+	return left, right
+}
+
+func TestCompareBytesAlignsInsertion(t *testing.T) {
+	left := []byte("\x00\x01\x02\x03\x04\x05\x06\x07")
+	right := []byte("\x00\x01\x02\xFF\xFE\x03\x04\x05\x06\x07")
+
+	diff, err := New().CompareBytes(context.Background(), left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := 0
+	for _, c := range diff.Cells {
+		if c.Changed() {
+			changed++
+			if c.Left != -1 {
+				t.Errorf("inserted bytes should face a gap, got %+v", c)
+			}
+		}
+	}
+	if changed != 2 {
+		t.Errorf("expected the 2 inserted bytes to be the only change, got %d", changed)
+	}
+
+	gotLeft, gotRight := sides(diff.Cells)
+	if !bytes.Equal(gotLeft, left) || !bytes.Equal(gotRight, right) {
+		t.Errorf("cells do not rebuild the inputs: %x / %x", gotLeft, gotRight)
+	}
+}
+
+func TestCompareBytesReplacementSharesCells(t *testing.T) {
+	diff, err := New().CompareBytes(context.Background(), []byte("ab\x10\x11cd"), []byte("ab\x20\x21cd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Cells) != 6 {
+		t.Fatalf("replaced bytes should be shown against each other, got %d cells", len(diff.Cells))
+	}
+	if c := diff.Cells[2]; c.Left != 0x10 || c.Right != 0x20 {
+		t.Errorf("unexpected cell %+v", c)
+	}
+}
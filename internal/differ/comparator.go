@@ -0,0 +1,291 @@
+package differ
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Comparator compares two files as documents of a format it understands,
+// rather than line by line
+type Comparator interface {
+	// Name identifies the comparator in configuration, e.g. "json"
+	Name() string
+
+	// Compare compares the contents of the left and right files. An error
+	// means they could not be read as the comparator's format, or that ctx
+	// was cancelled, in which case it returns ctx.Err() as soon as it can.
+	Compare(ctx context.Context, left, right string) (*StructuredDiff, error)
+}
+
+// OutlineComparator is a Comparator for documents organised in sections,
+// such as Markdown, that can also report how the sections changed alone
+type OutlineComparator interface {
+	Comparator
+
+	// CompareOutline compares the sections of the left and right files,
+	// returning those that differ
+	CompareOutline(ctx context.Context, left, right string) (Outline, error)
+}
+
+// LineComparison is the name Registry.Assign takes to have files compared
+// by the line diff alone
+const LineComparison = "lines"
+
+type comparatorFunc struct {
+	name string
+	fn   func(ctx context.Context, left, right string) (*StructuredDiff, error)
+}
+
+func (c comparatorFunc) Name() string { return c.name }
+
+func (c comparatorFunc) Compare(ctx context.Context, left, right string) (*StructuredDiff, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.fn(ctx, left, right)
+}
+
+// NewComparator returns a Comparator called name that compares with fn,
+// which is not called once ctx is cancelled
+func NewComparator(name string, fn func(ctx context.Context, left, right string) (*StructuredDiff, error)) Comparator {
+	return comparatorFunc{name: name, fn: fn}
+}
+
+// uncancellable adapts a comparison that cannot be interrupted: it runs in
+// the background, and the returned function gives up on it and returns
+// ctx.Err() when ctx is cancelled first, or does not start it at all when
+// ctx is cancelled already
+func uncancellable(fn func(left, right string) (*StructuredDiff, error)) func(context.Context, string, string) (*StructuredDiff, error) {
+	return func(ctx context.Context, left, right string) (*StructuredDiff, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		type result struct {
+			diff *StructuredDiff
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			diff, err := fn(left, right)
+			done <- result{diff, err}
+		}()
+		select {
+		case r := <-done:
+			return r.diff, r.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// StructuredOptions configures the built-in comparators
+type StructuredOptions struct {
+	JSON     JSONOptions
+	YAML     YAMLOptions
+	Table    TableOptions
+	KeyValue KeyValueOptions
+}
+
+// DefaultStructuredOptions returns the defaults of each built-in comparator
+func DefaultStructuredOptions() StructuredOptions {
+	return StructuredOptions{JSON: DefaultJSONOptions(), YAML: DefaultYAMLOptions()}
+}
+
+// BuiltinComparators returns the comparators this package provides, set up
+// with opts: json, yaml, csv, tsv, ini, env, properties, xml, html,
+// markdown and go
+func BuiltinComparators(opts StructuredOptions) []Comparator {
+	builtin := func(name string, fn func(l, r string) (*StructuredDiff, error)) Comparator {
+		return NewComparator(name, uncancellable(fn))
+	}
+	keyValue := func(name string, format KeyValueFormat) Comparator {
+		return builtin(name, func(l, r string) (*StructuredDiff, error) { return CompareKeyValue(l, r, format, opts.KeyValue) })
+	}
+	return []Comparator{
+		builtin("json", func(l, r string) (*StructuredDiff, error) { return CompareJSON(l, r, opts.JSON) }),
+		builtin("yaml", func(l, r string) (*StructuredDiff, error) { return CompareYAML(l, r, opts.YAML) }),
+		builtin("csv", func(l, r string) (*StructuredDiff, error) { return CompareTable(l, r, ',', opts.Table) }),
+		builtin("tsv", func(l, r string) (*StructuredDiff, error) { return CompareTable(l, r, '\t', opts.Table) }),
+		keyValue("ini", FormatINI),
+		keyValue("env", FormatEnv),
+		keyValue("properties", FormatProperties),
+		builtin("xml", CompareXML),
+		builtin("html", CompareHTML),
+		markdownComparator{},
+		builtin("go", CompareGo),
+	}
+}
+
+// builtinPatterns are the file names each built-in comparator is chosen for
+var builtinPatterns = map[string][]string{
+	"json":       {".json"},
+	"yaml":       {".yaml", ".yml"},
+	"csv":        {".csv"},
+	"tsv":        {".tsv"},
+	"ini":        {".ini", ".cfg"},
+	"env":        {".env", ".env.*"},
+	"properties": {".properties"},
+	"xml":        {".xml", ".xsd", ".xsl", ".xslt", ".svg", ".xhtml"},
+	"html":       {".html", ".htm"},
+	"markdown":   {".md", ".markdown"},
+	"go":         {".go"},
+}
+
+// Registry picks the comparator for a file: one the user assigned to its
+// name, else one registered for its name, else one whose sniffer recognises
+// its content. Files it finds none for are compared by the line diff alone.
+// Within each step, later registrations take precedence, so comparators
+// registered after the built-ins replace them. A Registry is safe for
+// concurrent use.
+type Registry struct {
+	mu          sync.RWMutex
+	comparators map[string]Comparator
+	patterns    []registryRule // from Register
+	assigned    []registryRule // from Assign
+	sniffers    []registrySniffer
+}
+
+type registryRule struct {
+	pattern, name string
+}
+
+type registrySniffer struct {
+	name  string
+	match func(content string) bool
+}
+
+// NewRegistry returns a registry without comparators
+func NewRegistry() *Registry {
+	return &Registry{comparators: make(map[string]Comparator)}
+}
+
+// NewDefaultRegistry returns a registry of the built-in comparators set up
+// with opts, chosen by file extension, and by content for JSON, XML and
+// HTML files whose names say nothing
+func NewDefaultRegistry(opts StructuredOptions) *Registry {
+	r := NewRegistry()
+	for _, c := range BuiltinComparators(opts) {
+		r.Register(c, builtinPatterns[c.Name()]...)
+	}
+	r.RegisterSniffer("json", looksLikeJSON)
+	r.RegisterSniffer("xml", func(content string) bool {
+		return strings.HasPrefix(strings.TrimSpace(content), "<?xml")
+	})
+	r.RegisterSniffer("html", func(content string) bool {
+		head := strings.ToLower(strings.TrimSpace(content))
+		return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+	})
+	return r
+}
+
+// Register adds c, replacing any comparator of the same name, and chooses
+// it for files matching patterns. A pattern is an extension such as
+// ".json", an exact file name, or a glob such as ".env.*" matched against
+// the base name; all case-insensitively.
+func (r *Registry) Register(c Comparator, patterns ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comparators[c.Name()] = c
+	for _, p := range patterns {
+		r.patterns = append(r.patterns, registryRule{pattern: strings.ToLower(p), name: c.Name()})
+	}
+}
+
+// RegisterSniffer has the comparator called name chosen for files that no
+// pattern matches but whose content match accepts
+func (r *Registry) RegisterSniffer(name string, match func(content string) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sniffers = append(r.sniffers, registrySniffer{name: name, match: match})
+}
+
+// Assign records the user's choice of comparator for files matching
+// pattern, ahead of everything registered. name may be LineComparison to
+// keep such files to the line diff. It fails for an unknown name or a
+// malformed pattern.
+func (r *Registry) Assign(pattern, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.comparators[name]; !ok && name != LineComparison {
+		return fmt.Errorf("unknown comparator %q (known: %s)", name, strings.Join(r.names(), ", "))
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	r.assigned = append(r.assigned, registryRule{pattern: strings.ToLower(pattern), name: name})
+	return nil
+}
+
+// Names returns the names of the registered comparators, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.names()
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.comparators)+1)
+	for name := range r.comparators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, LineComparison)
+}
+
+// Lookup returns the comparator for a file called name with the given
+// content, or nil if it should be compared by the line diff alone
+func (r *Registry) Lookup(name, content string) Comparator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	base := strings.ToLower(filepath.Base(name))
+	for _, rules := range [][]registryRule{r.assigned, r.patterns} {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if matchPattern(rules[i].pattern, base) {
+				return r.comparators[rules[i].name] // nil for LineComparison
+			}
+		}
+	}
+	for i := len(r.sniffers) - 1; i >= 0; i-- {
+		if c := r.comparators[r.sniffers[i].name]; c != nil && r.sniffers[i].match(content) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Compare compares two versions of the file called name with the comparator
+// Lookup picks, sniffing the left content or, if that is empty, the right.
+// It returns nil and no error when the file has no comparator, and ctx.Err()
+// when ctx is cancelled before the comparator finishes.
+func (r *Registry) Compare(ctx context.Context, name, left, right string) (*StructuredDiff, error) {
+	content := left
+	if strings.TrimSpace(content) == "" {
+		content = right
+	}
+	c := r.Lookup(name, content)
+	if c == nil {
+		return nil, nil
+	}
+	return c.Compare(ctx, left, right)
+}
+
+// matchPattern matches a lower-case base name against an extension, exact
+// name or glob pattern
+func matchPattern(pattern, base string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, _ := filepath.Match(pattern, base)
+		return ok
+	}
+	return base == pattern || (strings.HasPrefix(pattern, ".") && filepath.Ext(base) == pattern)
+}
+
+// looksLikeJSON reports whether content is a JSON object or array
+func looksLikeJSON(content string) bool {
+	content = strings.TrimSpace(content)
+	return (strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[")) && json.Valid([]byte(content))
+}
@@ -0,0 +1,160 @@
+package differ
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func comparatorName(c Comparator) string {
+	if c == nil {
+		return LineComparison
+	}
+	return c.Name()
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewDefaultRegistry(DefaultStructuredOptions())
+	tests := []struct {
+		name, content, want string
+	}{
+		{"config/app.JSON", "", "json"},
+		{"deploy.yml", "", "yaml"},
+		{".env", "", "env"},
+		{".env.local", "", "env"},
+		{"app.env", "", "env"},
+		{"docs/README.md", "", "markdown"},
+		{"main.go", "", "go"},
+		{"notes.txt", "just text", LineComparison},
+		{"response", `{"ok": true}`, "json"},
+		{"feed", "<?xml version=\"1.0\"?><rss/>", "xml"},
+		{"page", "<!DOCTYPE html><html></html>", "html"},
+		{"broken", `{"ok": `, LineComparison},
+	}
+	for _, tt := range tests {
+		if got := comparatorName(r.Lookup(tt.name, tt.content)); got != tt.want {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryAssign(t *testing.T) {
+	r := NewDefaultRegistry(DefaultStructuredOptions())
+	if err := r.Assign("*.conf", "ini"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Assign(".json", LineComparison); err != nil {
+		t.Fatal(err)
+	}
+	if got := comparatorName(r.Lookup("app.conf", "")); got != "ini" {
+		t.Errorf("app.conf: got %s, want ini", got)
+	}
+	if got := comparatorName(r.Lookup("data.json", `{}`)); got != LineComparison {
+		t.Errorf("data.json: got %s, want the line diff", got)
+	}
+	if err := r.Assign("*.x", "nope"); err == nil {
+		t.Error("expected an error for an unknown comparator")
+	}
+	if err := r.Assign("[", "ini"); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestRegistryCustomComparator(t *testing.T) {
+	r := NewDefaultRegistry(DefaultStructuredOptions())
+	custom := NewComparator("json", func(_ context.Context, left, right string) (*StructuredDiff, error) {
+		return &StructuredDiff{Format: "custom", Changes: []Change{{Kind: ChangeModified, Path: "all", Old: left, New: right}}}, nil
+	})
+	r.Register(custom, ".lock")
+
+	for _, name := range []string{"a.json", "yarn.lock"} {
+		diff, err := r.Compare(context.Background(), name, "1", "2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff == nil || diff.Format != "custom" {
+			t.Errorf("%s: expected the custom comparator, got %+v", name, diff)
+		}
+	}
+
+	if diff, err := r.Compare(context.Background(), "notes.txt", "a", "b"); diff != nil || err != nil {
+		t.Errorf("expected no comparator for notes.txt, got %+v, %v", diff, err)
+	}
+}
+
+func TestRegistryCompareCancelled(t *testing.T) {
+	r := NewDefaultRegistry(DefaultStructuredOptions())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if diff, err := r.Compare(ctx, "a.json", `{"a": 1}`, `{"a": 2}`); diff != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled comparison returned %+v, %v", diff, err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	slow := NewComparator("slow", uncancellable(func(_, _ string) (*StructuredDiff, error) {
+		close(started)
+		<-release
+		return &StructuredDiff{}, nil
+	}))
+	r.Register(slow, ".slow")
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	if _, err := r.Compare(ctx, "x.slow", "a", "b"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a running comparison to stop on cancel, got %v", err)
+	}
+}
+
+func TestOutlineComparator(t *testing.T) {
+	r := NewDefaultRegistry(DefaultStructuredOptions())
+	if _, ok := r.Lookup("config.json", "").(OutlineComparator); ok {
+		t.Error("expected the JSON comparator to have no outline")
+	}
+	c, ok := r.Lookup("README.md", "").(OutlineComparator)
+	if !ok {
+		t.Fatal("expected the Markdown comparator to have an outline")
+	}
+	outline, err := c.CompareOutline(context.Background(), "# T\n\n## A\nx\n", "# T\n\n## A\ny\n\n## B\nz\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, _, changed, _, _ := outline.Counts(); added != 1 || changed != 1 {
+		t.Errorf("outline counts %d added, %d changed; want 1, 1", added, changed)
+	}
+}
+
+func TestStructuredSummary(t *testing.T) {
+	diff := CompareMarkdown("# T\n\n## A\nx\n", "# T\n\n## A\ny\n\n## B\nz\n")
+	want := "Markdown: 1 added, 0 removed, 1 changed • Sections: 1 added, 0 removed, 1 changed"
+	if got := diff.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteChanges(t *testing.T) {
+	diff, err := CompareJSON(`{"a": 1, "b": 2}`, `{"a": 2, "c": 3}`, DefaultJSONOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteChanges(&buf, diff, "a/x.json", "b/x.json"); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"--- a/x.json",
+		"+++ b/x.json",
+		"# JSON: 1 added, 1 removed, 1 changed",
+		"~ $.a: 1 → 2",
+		"- $.b: 2",
+		"+ $.c: 3",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -2,9 +2,11 @@ package differ
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 )
 
 // DiffType represents the type of difference
@@ -20,9 +22,48 @@ const (
 type DiffLine struct {
 	Type         DiffType
 	Content      string
-	LineNum      int // Left line num for equal/delete; right line num for insert (unified view)
-	LeftLineNum  int // Left file line number; -1 if not applicable
-	RightLineNum int // Right file line number; -1 if not applicable
+	LineNum      int    // Left line num for equal/delete; right line num for insert (unified view)
+	LeftLineNum  int    // Left file line number; -1 if not applicable
+	RightLineNum int    // Right file line number; -1 if not applicable
+	Spans        []Span // Changed byte ranges within Content for modified pairs, or any changed line in token mode; nil otherwise
+
+	// RightContent holds the right-hand text of an equal line whose sides
+	// only matched under a relaxed comparison; Equalized says which one.
+	RightContent string
+	Equalized    Equalization
+
+	// Ignored marks an insert or delete that the active options treat as
+	// insignificant, such as a blank line under IgnoreBlankLines
+	Ignored bool
+
+	// EOL is the line's terminator; for equal lines, on the left side.
+	// RightEOL is the right side's terminator of an equal line.
+	EOL      LineEnding
+	RightEOL LineEnding
+
+	// MoveID links a deleted or inserted line to the other end of a moved
+	// block, indexing FileDiff.Moves from 1; 0 if the line was not moved
+	MoveID int
+
+	// Pair links a deleted line to the inserted line it was changed into,
+	// and back, indexing FileDiff.Lines from 1; 0 if the line is unpaired
+	Pair int
+}
+
+// RightText returns the line's text as it appears in the right file
+func (l DiffLine) RightText() string {
+	if l.Type == DiffEqual && l.Equalized != 0 {
+		return l.RightContent
+	}
+	return l.Content
+}
+
+// RightLineEnding returns the line's terminator in the right file
+func (l DiffLine) RightLineEnding() LineEnding {
+	if l.Type == DiffEqual {
+		return l.RightEOL
+	}
+	return l.EOL
 }
 
 // SideBySideRowType represents the type of an aligned side-by-side row
@@ -32,7 +73,7 @@ const (
 	SBSEqual    SideBySideRowType = iota
 	SBSInsert                     // new line on right only
 	SBSDelete                     // removed line on left only
-	SBSModified                   // adjacent delete+insert pair (changed line)
+	SBSModified                   // paired delete+insert (changed line)
 )
 
 // SideBySideRow is one aligned row in a side-by-side diff view
@@ -40,57 +81,117 @@ type SideBySideRow struct {
 	Type         SideBySideRowType
 	LeftContent  string
 	RightContent string
-	LeftLineNum  int // -1 if no left line
-	RightLineNum int // -1 if no right line
+	LeftLineNum  int    // -1 if no left line
+	RightLineNum int    // -1 if no right line
+	LeftSpans    []Span // changed byte ranges within LeftContent (Modified rows, and Delete rows in token mode)
+	RightSpans   []Span // changed byte ranges within RightContent (Modified rows, and Insert rows in token mode)
+	Equalized    Equalization
+	Ignored      bool
+	LeftEOL      LineEnding
+	RightEOL     LineEnding
+	MoveID       int // move the row's single changed line belongs to; 0 if none
+	LeftIndex    int // index of the left line in the source DiffLines; -1 if none
+	RightIndex   int // index of the right line in the source DiffLines; -1 if none
 }
 
 // BuildSideBySideRows converts diff lines into aligned side-by-side rows.
-// Adjacent Delete+Insert pairs are collapsed into a single Modified row so
-// changed lines appear side-by-side rather than on separate rows.
+// Paired Delete+Insert lines are collapsed into a single Modified row so
+// changed lines appear side-by-side rather than on separate rows; unpaired
+// lines of a change block get rows of their own between the pairs.
 func BuildSideBySideRows(lines []DiffLine) []SideBySideRow {
 	rows := make([]SideBySideRow, 0, len(lines))
 	i := 0
 	for i < len(lines) {
 		line := lines[i]
-		switch line.Type {
-		case DiffEqual:
+		if line.Type == DiffEqual {
 			rows = append(rows, SideBySideRow{
 				Type:         SBSEqual,
 				LeftContent:  line.Content,
-				RightContent: line.Content,
+				RightContent: line.RightText(),
 				LeftLineNum:  line.LeftLineNum,
 				RightLineNum: line.RightLineNum,
+				Equalized:    line.Equalized,
+				LeftEOL:      line.EOL,
+				RightEOL:     line.RightEOL,
+				LeftIndex:    i,
+				RightIndex:   i,
 			})
 			i++
-		case DiffDelete:
-			if i+1 < len(lines) && lines[i+1].Type == DiffInsert {
-				rows = append(rows, SideBySideRow{
-					Type:         SBSModified,
-					LeftContent:  line.Content,
-					RightContent: lines[i+1].Content,
-					LeftLineNum:  line.LeftLineNum,
-					RightLineNum: lines[i+1].RightLineNum,
-				})
-				i += 2
-			} else {
-				rows = append(rows, SideBySideRow{
-					Type:         SBSDelete,
-					LeftContent:  line.Content,
-					RightContent: "",
-					LeftLineNum:  line.LeftLineNum,
-					RightLineNum: -1,
-				})
-				i++
-			}
-		case DiffInsert:
+			continue
+		}
+
+		end := changeBlockEnd(lines, i)
+		rows = appendBlockRows(rows, lines, i, end)
+		i = end
+	}
+	return rows
+}
+
+// appendBlockRows appends the rows of the change block lines[start:end].
+// Pairs never cross, so walking both sides in order meets each pair's
+// delete and insert together.
+func appendBlockRows(rows []SideBySideRow, lines []DiffLine, start, end int) []SideBySideRow {
+	var dels, ins []int
+	for i := start; i < end; i++ {
+		if lines[i].Type == DiffDelete {
+			dels = append(dels, i)
+		} else {
+			ins = append(ins, i)
+		}
+	}
+
+	p, q := 0, 0
+	for p < len(dels) || q < len(ins) {
+		switch {
+		case p < len(dels) && (lines[dels[p]].Pair == 0 || q == len(ins)):
+			line := lines[dels[p]]
+			rows = append(rows, SideBySideRow{
+				Type:         SBSDelete,
+				LeftContent:  line.Content,
+				RightContent: "",
+				LeftLineNum:  line.LeftLineNum,
+				RightLineNum: -1,
+				LeftSpans:    line.Spans,
+				Ignored:      line.Ignored,
+				LeftEOL:      line.EOL,
+				MoveID:       line.MoveID,
+				LeftIndex:    dels[p],
+				RightIndex:   -1,
+			})
+			p++
+		case q < len(ins) && (lines[ins[q]].Pair == 0 || p == len(dels)):
+			line := lines[ins[q]]
 			rows = append(rows, SideBySideRow{
 				Type:         SBSInsert,
 				LeftContent:  "",
 				RightContent: line.Content,
 				LeftLineNum:  -1,
 				RightLineNum: line.RightLineNum,
+				RightSpans:   line.Spans,
+				Ignored:      line.Ignored,
+				RightEOL:     line.EOL,
+				MoveID:       line.MoveID,
+				LeftIndex:    -1,
+				RightIndex:   ins[q],
 			})
-			i++
+			q++
+		default:
+			left, right := lines[dels[p]], lines[ins[q]]
+			rows = append(rows, SideBySideRow{
+				Type:         SBSModified,
+				LeftContent:  left.Content,
+				RightContent: right.Content,
+				LeftLineNum:  left.LeftLineNum,
+				RightLineNum: right.RightLineNum,
+				LeftSpans:    left.Spans,
+				RightSpans:   right.Spans,
+				LeftEOL:      left.EOL,
+				RightEOL:     right.EOL,
+				LeftIndex:    dels[p],
+				RightIndex:   ins[q],
+			})
+			p++
+			q++
 		}
 	}
 	return rows
@@ -101,209 +202,342 @@ type FileDiff struct {
 	LeftFile  string
 	RightFile string
 	Lines     []DiffLine
+
+	// Line endings used by each file, and whether its last line lacks a
+	// terminating newline
+	LeftEOL             LineEnding
+	RightEOL            LineEnding
+	LeftNoFinalNewline  bool
+	RightNoFinalNewline bool
+	Moves               []Move // blocks moved from one place to another
+
+	// Approximate is set when the diff hit its MaxCost or TimeBudget and
+	// fell back to a heuristic, so it may show more changes than necessary
+	Approximate bool
+}
+
+// Options controls how a Differ matches lines
+type Options struct {
+	Algorithm        Algorithm
+	Granularity      Granularity // intra-line comparison of modified pairs
+	Whitespace       WhitespaceMode
+	FoldCase         bool          // letters compare equal whatever their case
+	Normalization    Normalization // Unicode normal form lines are compared in
+	IgnoreBlankLines bool          // added or removed blank lines are not treated as changes
+	IgnoreRules      []IgnoreRule
+	IgnoreEOL        bool // CRLF, LF and a missing final newline compare equal
+	DetectMoves      bool // tag deleted blocks that reappear elsewhere as moves
+
+	// MaxCost and TimeBudget bound the search for a minimal diff, in edit
+	// graph steps and in time; zero means no limit. See FileDiff.Approximate.
+	MaxCost    int
+	TimeBudget time.Duration
+}
+
+// DefaultOptions returns the options used by New. They set no TimeBudget,
+// so that a diff does not depend on how busy the machine is.
+func DefaultOptions() Options {
+	return Options{
+		Algorithm:   AlgorithmMyers,
+		Granularity: GranularityWord,
+		Whitespace:  WhitespaceExact,
+		DetectMoves: true,
+	}
 }
 
 // Differ handles file comparison operations
-type Differ struct{}
+type Differ struct {
+	Options
+}
 
 // New creates a new Differ instance
 func New() *Differ {
-	return &Differ{}
+	return NewWithOptions(DefaultOptions())
+}
+
+// NewWithOptions creates a Differ with the given options
+func NewWithOptions(opts Options) *Differ {
+	return &Differ{Options: opts}
 }
 
 // CompareFiles compares two files and returns a structured diff
 func (d *Differ) CompareFiles(leftPath, rightPath string, leftContent, rightContent io.Reader) (*FileDiff, error) {
-	leftLines, err := readLines(leftContent)
+	leftLines, leftNoNewline, err := readLines(leftContent)
 	if err != nil {
 		return nil, fmt.Errorf("error reading left file: %w", err)
 	}
 
-	rightLines, err := readLines(rightContent)
+	rightLines, rightNoNewline, err := readLines(rightContent)
 	if err != nil {
 		return nil, fmt.Errorf("error reading right file: %w", err)
 	}
 
-	return d.compareLines(leftPath, rightPath, leftLines, rightLines), nil
+	diff := &FileDiff{
+		LeftFile:            leftPath,
+		RightFile:           rightPath,
+		LeftNoFinalNewline:  leftNoNewline,
+		RightNoFinalNewline: rightNoNewline,
+	}
+	if err := d.compareLines(context.Background(), diff, leftLines, rightLines); err != nil {
+		return nil, err
+	}
+	return diff, nil
 }
 
 // CompareStrings compares two strings and returns a structured diff
 func (d *Differ) CompareStrings(leftPath, rightPath, leftContent, rightContent string) *FileDiff {
-	leftLines := strings.Split(leftContent, "\n")
-	rightLines := strings.Split(rightContent, "\n")
-	return d.compareLines(leftPath, rightPath, leftLines, rightLines)
+	// Only cancellation fails, and the background context is never cancelled
+	diff, _ := d.CompareContext(context.Background(), leftPath, rightPath, leftContent, rightContent)
+	return diff
 }
 
-// compareLines performs a true line-level diff using LCS.
-// Each DiffLine corresponds to exactly one source line — no partial-line
-// chunks, no spurious empty entries.
-func (d *Differ) compareLines(leftPath, rightPath string, leftLines, rightLines []string) *FileDiff {
+// CompareContext is CompareStrings for diffs that may take a while. It
+// returns ctx.Err() if ctx is cancelled or its deadline passes before the
+// diff is done. The MaxCost and TimeBudget options bound the search instead
+// of failing it: past them the result is marked Approximate.
+func (d *Differ) CompareContext(ctx context.Context, leftPath, rightPath, leftContent, rightContent string) (*FileDiff, error) {
 	diff := &FileDiff{
-		LeftFile:  leftPath,
-		RightFile: rightPath,
-		Lines:     make([]DiffLine, 0),
+		LeftFile:            leftPath,
+		RightFile:           rightPath,
+		LeftNoFinalNewline:  missingFinalNewline(leftContent),
+		RightNoFinalNewline: missingFinalNewline(rightContent),
 	}
-
-	// Drop the trailing empty string that strings.Split produces for
-	// content ending in "\n" (e.g. "a\nb\n" → ["a","b",""])
-	if len(leftLines) > 0 && leftLines[len(leftLines)-1] == "" {
-		leftLines = leftLines[:len(leftLines)-1]
+	if err := d.compareLines(ctx, diff, splitLines(leftContent), splitLines(rightContent)); err != nil {
+		return nil, err
 	}
-	if len(rightLines) > 0 && rightLines[len(rightLines)-1] == "" {
-		rightLines = rightLines[:len(rightLines)-1]
+	return diff, nil
+}
+
+func missingFinalNewline(content string) bool {
+	return content != "" && !strings.HasSuffix(content, "\n")
+}
+
+// splitLines splits content on "\n", dropping the empty string Split
+// produces after a final newline (e.g. "a\nb\n" → ["a","b"])
+func splitLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
+	return lines
+}
+
+// compareLines fills diff.Lines with a true line-level diff using the
+// configured algorithm. Each DiffLine corresponds to exactly one source
+// line — no partial-line chunks, no spurious empty entries.
+func (d *Differ) compareLines(ctx context.Context, diff *FileDiff, leftLines, rightLines []string) error {
+	diff.Lines = make([]DiffLine, 0)
+
+	leftEOLs := splitEOLs(leftLines, diff.LeftNoFinalNewline)
+	rightEOLs := splitEOLs(rightLines, diff.RightNoFinalNewline)
+	diff.LeftEOL, diff.RightEOL = fileLineEnding(leftEOLs), fileLineEnding(rightEOLs)
 
 	m, n := len(leftLines), len(rightLines)
-	matches := lcsMatches(leftLines, rightLines)
+	steps := d.normalizers()
+	key := lineKey(steps)
+	bud := newBudget(ctx, d.Options)
+	matches := d.match(leftLines, rightLines, d.lineKeys(leftLines, leftEOLs, key), d.lineKeys(rightLines, rightEOLs, key), bud)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	diff.Approximate = bud.approximate()
 
 	leftLineNum := 1
 	rightLineNum := 1
 	li := 0
 	ri := 0
 
-	for _, match := range matches {
-		leftIdx, rightIdx := match[0], match[1]
-
-		// Lines on the left before this match are deletions
-		for li < leftIdx {
+	deleteUntil := func(end int) {
+		for li < end {
 			diff.Lines = append(diff.Lines, DiffLine{
 				Type:         DiffDelete,
 				Content:      leftLines[li],
 				LineNum:      leftLineNum,
 				LeftLineNum:  leftLineNum,
 				RightLineNum: -1,
+				Ignored:      d.IgnoreBlankLines && isBlank(leftLines[li]),
+				EOL:          leftEOLs[li],
 			})
 			li++
 			leftLineNum++
 		}
-
-		// Lines on the right before this match are insertions
-		for ri < rightIdx {
+	}
+	insertUntil := func(end int) {
+		for ri < end {
 			diff.Lines = append(diff.Lines, DiffLine{
 				Type:         DiffInsert,
 				Content:      rightLines[ri],
 				LineNum:      rightLineNum,
 				LeftLineNum:  -1,
 				RightLineNum: rightLineNum,
+				Ignored:      d.IgnoreBlankLines && isBlank(rightLines[ri]),
+				EOL:          rightEOLs[ri],
 			})
 			ri++
 			rightLineNum++
 		}
+	}
 
-		// The matched line (equal on both sides)
-		diff.Lines = append(diff.Lines, DiffLine{
+	for _, match := range matches {
+		// Lines before this match are deletions (left) and insertions (right)
+		deleteUntil(match[0])
+		insertUntil(match[1])
+
+		// The matched line (equal on both sides, possibly after normalization)
+		line := DiffLine{
 			Type:         DiffEqual,
 			Content:      leftLines[li],
 			LineNum:      leftLineNum,
 			LeftLineNum:  leftLineNum,
 			RightLineNum: rightLineNum,
-		})
+			EOL:          leftEOLs[li],
+			RightEOL:     rightEOLs[ri],
+		}
+		if right := rightLines[ri]; right != line.Content {
+			line.Equalized = equalizationReason(steps, line.Content, right)
+			if line.Equalized == 0 {
+				// Blank lines paired under IgnoreBlankLines
+				line.Equalized = EqualizedWhitespace
+			}
+		}
+		if line.EOL != line.RightEOL {
+			line.Equalized |= EqualizedEOL
+		}
+		if line.Equalized != 0 {
+			line.RightContent = rightLines[ri]
+		}
+		diff.Lines = append(diff.Lines, line)
 		li++
 		ri++
 		leftLineNum++
 		rightLineNum++
 	}
 
-	// Remaining left lines are deletions
-	for li < m {
-		diff.Lines = append(diff.Lines, DiffLine{
-			Type:         DiffDelete,
-			Content:      leftLines[li],
-			LineNum:      leftLineNum,
-			LeftLineNum:  leftLineNum,
-			RightLineNum: -1,
-		})
-		li++
-		leftLineNum++
+	// Remaining lines are deletions and insertions
+	deleteUntil(m)
+	insertUntil(n)
+
+	if d.DetectMoves {
+		diff.Moves = detectMoves(diff.Lines)
 	}
+	pairChanges(diff.Lines)
+	d.annotateInline(diff.Lines)
+	return ctx.Err()
+}
 
-	// Remaining right lines are insertions
-	for ri < n {
-		diff.Lines = append(diff.Lines, DiffLine{
-			Type:         DiffInsert,
-			Content:      rightLines[ri],
-			LineNum:      rightLineNum,
-			LeftLineNum:  -1,
-			RightLineNum: rightLineNum,
-		})
-		ri++
-		rightLineNum++
+// match returns the matched (leftIndex, rightIndex) pairs for the configured
+// algorithm, comparing lines by their keys. With IgnoreBlankLines, blank lines are
+// left out of the main match so they never anchor the alignment, and are
+// then paired up within each gap.
+func (d *Differ) match(leftLines, rightLines, leftKeys, rightKeys []string, bud *budget) [][2]int {
+	if !d.IgnoreBlankLines {
+		a, b := internLines(leftKeys, rightKeys, identity)
+		return matchLines(d.Algorithm, a, b, bud)
 	}
 
-	return diff
+	leftIdx, rightIdx := nonBlankIndexes(leftLines), nonBlankIndexes(rightLines)
+	a, b := internLines(pick(leftKeys, leftIdx), pick(rightKeys, rightIdx), identity)
+	matches := matchLines(d.Algorithm, a, b, bud)
+	for i, mt := range matches {
+		matches[i] = [2]int{leftIdx[mt[0]], rightIdx[mt[1]]}
+	}
+	return pairBlankLines(leftLines, rightLines, matches)
 }
 
-// lcsMatches returns the matched (leftIndex, rightIndex) pairs from the
-// Longest Common Subsequence of left and right.
-func lcsMatches(left, right []string) [][2]int {
-	m, n := len(left), len(right)
-	if m == 0 || n == 0 {
-		return nil
+// lineKeys returns the comparison key of every line. Unless IgnoreEOL is
+// set, a line's terminator is part of its key.
+func (d *Differ) lineKeys(lines []string, eols []LineEnding, key func(string) string) []string {
+	keys := make([]string, len(lines))
+	for i, line := range lines {
+		keys[i] = key(line)
+		if !d.IgnoreEOL {
+			keys[i] += eols[i].keySuffix()
+		}
 	}
+	return keys
+}
 
-	// dp[i][j] = LCS length of left[:i] and right[:j]
-	dp := make([][]int, m+1)
-	for i := range dp {
-		dp[i] = make([]int, n+1)
-	}
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			if left[i-1] == right[j-1] {
-				dp[i][j] = dp[i-1][j-1] + 1
-			} else if dp[i-1][j] >= dp[i][j-1] {
-				dp[i][j] = dp[i-1][j]
-			} else {
-				dp[i][j] = dp[i][j-1]
+// pairBlankLines adds matches for blank lines that sit in the same gap
+// between two existing matches, pairing them in order.
+func pairBlankLines(leftLines, rightLines []string, matches [][2]int) [][2]int {
+	out := make([][2]int, 0, len(matches))
+	li, ri := 0, 0
+	pairGap := func(leftEnd, rightEnd int) {
+		var leftBlank, rightBlank []int
+		for i := li; i < leftEnd; i++ {
+			if isBlank(leftLines[i]) {
+				leftBlank = append(leftBlank, i)
 			}
 		}
+		for j := ri; j < rightEnd; j++ {
+			if isBlank(rightLines[j]) {
+				rightBlank = append(rightBlank, j)
+			}
+		}
+		for k := 0; k < len(leftBlank) && k < len(rightBlank); k++ {
+			out = append(out, [2]int{leftBlank[k], rightBlank[k]})
+		}
 	}
 
-	// Backtrack to collect matched pairs
-	matches := make([][2]int, 0, dp[m][n])
-	i, j := m, n
-	for i > 0 && j > 0 {
-		if left[i-1] == right[j-1] {
-			matches = append([][2]int{{i - 1, j - 1}}, matches...)
-			i--
-			j--
-		} else if dp[i-1][j] >= dp[i][j-1] {
-			i--
-		} else {
-			j--
-		}
+	for _, mt := range matches {
+		pairGap(mt[0], mt[1])
+		out = append(out, mt)
+		li, ri = mt[0]+1, mt[1]+1
 	}
-	return matches
+	pairGap(len(leftLines), len(rightLines))
+	return out
 }
 
-// readLines reads all lines from a reader
-func readLines(r io.Reader) ([]string, error) {
-	var lines []string
-	scanner := bufio.NewScanner(r)
-
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+func nonBlankIndexes(lines []string) []int {
+	var idx []int
+	for i, line := range lines {
+		if !isBlank(line) {
+			idx = append(idx, i)
+		}
 	}
+	return idx
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+func pick(lines []string, idx []int) []string {
+	out := make([]string, len(idx))
+	for i, k := range idx {
+		out[i] = lines[k]
 	}
+	return out
+}
 
-	return lines, nil
+// identity is the line key used when lines must match exactly
+func identity(s string) string {
+	return s
 }
 
-// GetStats returns statistics about the diff
-func (fd *FileDiff) GetStats() (int, int, int) {
-	equal, inserted, deleted := 0, 0, 0
-
-	for _, line := range fd.Lines {
-		switch line.Type {
-		case DiffEqual:
-			equal++
-		case DiffInsert:
-			inserted++
-		case DiffDelete:
-			deleted++
+// readLines reads all lines from a reader, without a length limit, and
+// reports whether the last line lacked a terminating newline. CRs are kept
+// for compareLines to record as line endings.
+func readLines(r io.Reader) ([]string, bool, error) {
+	var lines []string
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		if line != "" {
+			if !strings.HasSuffix(line, "\n") {
+				return append(lines, line), true, nil
+			}
+			lines = append(lines, line[:len(line)-1])
+		}
+		if err == io.EOF {
+			return lines, false, nil
 		}
 	}
+}
 
-	return equal, inserted, deleted
+// GetStats returns statistics about the diff. Ignored changes count as equal.
+// See Stats for modified pairs, hunks and similarity.
+func (fd *FileDiff) GetStats() (int, int, int) {
+	s := countLines(fd.Lines, 0)
+	return s.Equal, s.Inserted, s.Deleted
 }
@@ -1,8 +1,11 @@
 package differ
 
 import (
+	"errors"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 // ---- helpers ----------------------------------------------------------------
@@ -339,3 +342,11 @@ func TestCompareFiles(t *testing.T) {
 		t.Errorf("expected insertions and deletions, got ins=%d del=%d", ins, del)
 	}
 }
+
+func TestCompareFilesReadError(t *testing.T) {
+	failing := errors.New("disk failure")
+	left := io.MultiReader(strings.NewReader("hello\nwor"), iotest.ErrReader(failing))
+	if _, err := New().CompareFiles("l", "r", left, strings.NewReader("hello\n")); !errors.Is(err, failing) {
+		t.Errorf("expected the read error mid-line to be reported, got %v", err)
+	}
+}
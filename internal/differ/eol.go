@@ -0,0 +1,95 @@
+package differ
+
+import "strings"
+
+// LineEnding identifies how a line, or a whole file, is terminated
+type LineEnding int
+
+const (
+	EOLNone  LineEnding = iota // no terminator: an unterminated last line, or a file with no lines
+	EOLLF                      // "\n"
+	EOLCRLF                    // "\r\n"
+	EOLMixed                   // files only: more than one terminator style is used
+)
+
+// String returns the conventional name of the line ending
+func (e LineEnding) String() string {
+	switch e {
+	case EOLLF:
+		return "LF"
+	case EOLCRLF:
+		return "CRLF"
+	case EOLMixed:
+		return "mixed"
+	default:
+		return "none"
+	}
+}
+
+// Terminator returns the bytes that end a line with this ending
+func (e LineEnding) Terminator() string {
+	switch e {
+	case EOLLF:
+		return "\n"
+	case EOLCRLF:
+		return "\r\n"
+	default:
+		return ""
+	}
+}
+
+// keySuffix distinguishes line endings in comparison keys. Only an
+// unterminated line's text can end in CR and no text contains LF, so these
+// suffixes never collide with content.
+func (e LineEnding) keySuffix() string {
+	switch e {
+	case EOLCRLF:
+		return "\r"
+	case EOLNone:
+		return "\n"
+	default:
+		return ""
+	}
+}
+
+// splitEOLs strips the CR of CRLF-terminated lines in place and returns each
+// line's ending. lines come from splitting on "\n"; the last one is
+// unterminated when noFinalNewline is set and keeps any CR as content.
+func splitEOLs(lines []string, noFinalNewline bool) []LineEnding {
+	eols := make([]LineEnding, len(lines))
+	for i, line := range lines {
+		switch {
+		case noFinalNewline && i == len(lines)-1:
+			eols[i] = EOLNone
+		case strings.HasSuffix(line, "\r"):
+			lines[i] = line[:len(line)-1]
+			eols[i] = EOLCRLF
+		default:
+			eols[i] = EOLLF
+		}
+	}
+	return eols
+}
+
+// fileLineEnding summarises the terminators used by a file's lines
+func fileLineEnding(eols []LineEnding) LineEnding {
+	result := EOLNone
+	for _, e := range eols {
+		if e == EOLNone {
+			continue
+		}
+		if result == EOLNone {
+			result = e
+		} else if result != e {
+			return EOLMixed
+		}
+	}
+	return result
+}
+
+// EOLDiffers reports whether the two files end their lines differently,
+// either in terminator style or in whether the last line is terminated
+func (fd *FileDiff) EOLDiffers() bool {
+	return fd.LeftEOL != fd.RightEOL || fd.LeftEOL == EOLMixed ||
+		fd.LeftNoFinalNewline != fd.RightNoFinalNewline
+}
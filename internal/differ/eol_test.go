@@ -0,0 +1,96 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCRLFContentHasNoCR(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "a\r\nb\r\n", "a\r\nb\r\n")
+	if hasChanges(diff) {
+		t.Fatalf("identical CRLF files should have no changes: %+v", diff.Lines)
+	}
+	for _, line := range diff.Lines {
+		if strings.Contains(line.Content, "\r") {
+			t.Errorf("content %q should not carry the CR", line.Content)
+		}
+		if line.EOL != EOLCRLF {
+			t.Errorf("line %q: EOL = %v, want CRLF", line.Content, line.EOL)
+		}
+	}
+	if diff.LeftEOL != EOLCRLF || diff.RightEOL != EOLCRLF {
+		t.Errorf("file EOLs = %v/%v, want CRLF/CRLF", diff.LeftEOL, diff.RightEOL)
+	}
+}
+
+func TestLineEndingsDiffer(t *testing.T) {
+	left, right := "a\r\nb\r\n", "a\nb\n"
+
+	diff := New().CompareStrings("l", "r", left, right)
+	if _, inserted, deleted := diff.GetStats(); inserted != 2 || deleted != 2 {
+		t.Errorf("CRLF vs LF should change every line, got +%d -%d", inserted, deleted)
+	}
+	if !diff.EOLDiffers() {
+		t.Error("EOLDiffers should report CRLF vs LF")
+	}
+
+	diff = NewWithOptions(Options{IgnoreEOL: true}).CompareStrings("l", "r", left, right)
+	if hasChanges(diff) {
+		t.Fatalf("IgnoreEOL should hide CRLF vs LF: %+v", diff.Lines)
+	}
+	line := diff.Lines[0]
+	if line.Equalized != EqualizedEOL || line.EOL != EOLCRLF || line.RightLineEnding() != EOLLF {
+		t.Errorf("unexpected equal line: %+v", line)
+	}
+	if line.RightText() != "a" {
+		t.Errorf("RightText = %q, want %q", line.RightText(), "a")
+	}
+}
+
+func TestIgnoreEOLCoversFinalNewline(t *testing.T) {
+	diff := NewWithOptions(Options{IgnoreEOL: true}).CompareStrings("l", "r", "a\nb", "a\nb\n")
+	if hasChanges(diff) {
+		t.Errorf("IgnoreEOL should hide a missing final newline: %+v", diff.Lines)
+	}
+}
+
+func TestFileLineEnding(t *testing.T) {
+	cases := []struct {
+		content string
+		want    LineEnding
+	}{
+		{"", EOLNone},
+		{"a", EOLNone},
+		{"a\nb", EOLLF},
+		{"a\r\nb\r\n", EOLCRLF},
+		{"a\r\nb\n", EOLMixed},
+	}
+	for _, c := range cases {
+		diff := New().CompareStrings("l", "r", c.content, "")
+		if diff.LeftEOL != c.want {
+			t.Errorf("%q: LeftEOL = %v, want %v", c.content, diff.LeftEOL, c.want)
+		}
+	}
+}
+
+func TestCompareFilesLongLines(t *testing.T) {
+	// Minified files easily exceed bufio.Scanner's 64KB token limit
+	long := strings.Repeat("x", 200*1024)
+	diff, err := New().CompareFiles("l", "r", strings.NewReader(long+"\n"), strings.NewReader(long+"y\n"))
+	if err != nil {
+		t.Fatalf("CompareFiles: %v", err)
+	}
+	if len(diff.Lines) != 2 || len(diff.Lines[0].Content) != len(long) {
+		t.Errorf("expected the long line to be read whole, got %d lines", len(diff.Lines))
+	}
+}
+
+func TestCompareFilesKeepsTrailingBlankLine(t *testing.T) {
+	diff, err := New().CompareFiles("l", "r", strings.NewReader("a\n\n"), strings.NewReader("a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, deleted := diff.GetStats(); deleted != 1 {
+		t.Errorf("the trailing blank line should show as deleted, got %d deletions", deleted)
+	}
+}
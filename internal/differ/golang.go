@@ -0,0 +1,258 @@
+package differ
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// CompareGo compares two Go source files declaration by declaration:
+// imports, functions, methods, types, constants and variables that were
+// added, removed or changed. A function whose signature changed reports
+// both signatures; one whose body alone changed says so. Fields and
+// methods of struct and interface types are compared one by one.
+// Declarations that share a name, such as several func init, are matched
+// in order and numbered ("func init#2"); blank ones such as
+// var _ I = (*T)(nil) go by their type, as "var _ I".
+// Formatting and comments are ignored. Changes carry the line of the
+// declaration in each file. It fails if either side does not parse.
+func CompareGo(left, right string) (*StructuredDiff, error) {
+	a, err := goDecls(left)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing left Go file: %w", err)
+	}
+	b, err := goDecls(right)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing right Go file: %w", err)
+	}
+	diff := &StructuredDiff{Format: "Go"}
+	diff.Changes = compareDecls(a, b, "")
+	return diff, nil
+}
+
+// goDecl is one top-level declaration, or a field or method of a struct or
+// interface type
+type goDecl struct {
+	key     string // e.g. "func main", "method Model.View", "type Model"
+	summary string // what a change shows: a signature, type or value
+	text    string // the whole declaration, formatted, for equality
+	body    bool   // a function whose text covers a body beyond summary
+	params  string // a generic type's type parameters, e.g. "[K comparable]"
+	line    int
+	members []goDecl // fields or methods of a struct or interface type
+}
+
+// goDecls parses src and lists its declarations in source order
+func goDecls(src string) ([]goDecl, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	line := func(n ast.Node) int { return fset.Position(n.Pos()).Line }
+
+	var decls []goDecl
+	for _, imp := range f.Imports {
+		key := "import " + imp.Path.Value
+		if imp.Name != nil {
+			key = "import " + imp.Name.Name + " " + imp.Path.Value
+		}
+		decls = append(decls, goDecl{key: key, summary: imp.Path.Value, text: key, line: line(imp)})
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			sig := goText(&ast.FuncDecl{Recv: d.Recv, Name: d.Name, Type: d.Type})
+			key := "func " + d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				key = "method " + receiverName(d.Recv.List[0].Type) + "." + d.Name.Name
+			}
+			decls = append(decls, goDecl{key: key, summary: sig, text: goText(d), body: d.Body != nil, line: line(d)})
+
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					decl := goDecl{key: "type " + s.Name.Name, text: goText(s), params: typeParams(s.TypeParams), line: line(s)}
+					decl.summary = decl.text
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						decl.summary, decl.members = "struct", goMembers(t.Fields, line)
+					case *ast.InterfaceType:
+						decl.summary, decl.members = "interface", goMembers(t.Methods, line)
+					}
+					decls = append(decls, decl)
+
+				case *ast.ValueSpec:
+					kind := d.Tok.String()
+					for i, name := range s.Names {
+						// Each name with its own type and value, so a
+						// change to one is not pinned on its neighbours
+						one := &ast.ValueSpec{Names: []*ast.Ident{name}, Type: s.Type}
+						if i < len(s.Values) {
+							one.Values = []ast.Expr{s.Values[i]}
+						}
+						text := goText(one)
+						key := kind + " " + name.Name
+						if name.Name == "_" && s.Type != nil {
+							key += " " + goText(s.Type)
+						}
+						decls = append(decls, goDecl{key: key, summary: text, text: text, line: line(name)})
+					}
+				}
+			}
+		}
+	}
+	numberRepeats(decls)
+	return decls, nil
+}
+
+// numberRepeats appends "#2", "#3" and so on to the keys of declarations
+// that repeat an earlier one's, so that they are matched in order
+func numberRepeats(decls []goDecl) {
+	seen := make(map[string]int, len(decls))
+	for i := range decls {
+		key := decls[i].key
+		if seen[key]++; seen[key] > 1 {
+			decls[i].key = fmt.Sprintf("%s#%d", key, seen[key])
+		}
+	}
+}
+
+// typeParams formats a type's parameter list as "[K comparable, V any]",
+// or "no type parameters"
+func typeParams(list *ast.FieldList) string {
+	if list == nil || len(list.List) == 0 {
+		return "no type parameters"
+	}
+	params := make([]string, len(list.List))
+	for i, f := range list.List {
+		names := make([]string, len(f.Names))
+		for j, name := range f.Names {
+			names[j] = name.Name
+		}
+		params[i] = strings.Join(names, ", ") + " " + goText(f.Type)
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// sameOrder reports whether the same members come in the same order
+func sameOrder(a, b []goDecl) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].key != b[i].key {
+			return false
+		}
+	}
+	return true
+}
+
+// receiverName returns the base type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return "?"
+}
+
+// goText formats a node as gofmt would, but from the syntax alone: without
+// the source positions the printer would keep the original line breaks of,
+// so that reflowing code does not change its text
+func goText(node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), node); err != nil {
+		return fmt.Sprint(node)
+	}
+	return buf.String()
+}
+
+// members lists the fields or methods of a struct or interface type.
+// Embedded ones are named by their type.
+func goMembers(fields *ast.FieldList, line func(ast.Node) int) []goDecl {
+	if fields == nil {
+		return nil
+	}
+	var out []goDecl
+	for _, f := range fields.List {
+		typ := goText(f.Type)
+		if f.Tag != nil {
+			typ += " " + f.Tag.Value
+		}
+		if len(f.Names) == 0 {
+			out = append(out, goDecl{key: typ, summary: "embedded", text: typ, line: line(f)})
+			continue
+		}
+		for _, name := range f.Names {
+			out = append(out, goDecl{key: name.Name, summary: typ, text: typ, line: line(name)})
+		}
+	}
+	return out
+}
+
+// compareDecls matches declarations by key: changed and removed ones in
+// left order, then added ones in right order. prefix qualifies the paths
+// of members.
+func compareDecls(a, b []goDecl, prefix string) []Change {
+	right := make(map[string]goDecl, len(b))
+	for _, d := range b {
+		right[d.key] = d
+	}
+	seen := make(map[string]bool, len(a))
+
+	var out []Change
+	for _, ad := range a {
+		seen[ad.key] = true
+		path := prefix + ad.key
+		bd, ok := right[ad.key]
+		switch {
+		case !ok:
+			out = append(out, Change{Kind: ChangeRemoved, Path: path, Old: ad.summary, LeftLine: ad.line})
+		case ad.text == bd.text:
+		case ad.members != nil || bd.members != nil:
+			if ad.summary != bd.summary {
+				// A struct became an interface, or the like
+				out = append(out, Change{Kind: ChangeModified, Path: path, Old: ad.summary, New: bd.summary, LeftLine: ad.line, RightLine: bd.line})
+				continue
+			}
+			if ad.params != bd.params {
+				out = append(out, Change{Kind: ChangeModified, Path: path, Old: ad.params, New: bd.params, LeftLine: ad.line, RightLine: bd.line})
+			}
+			members := compareDecls(ad.members, bd.members, path+".")
+			if len(members) == 0 && !sameOrder(ad.members, bd.members) {
+				note := "fields reordered"
+				if ad.summary == "interface" {
+					note = "methods reordered"
+				}
+				members = []Change{{Kind: ChangeModified, Path: path, Note: note, LeftLine: ad.line, RightLine: bd.line}}
+			}
+			out = append(out, members...)
+		case ad.summary == bd.summary && ad.body:
+			out = append(out, Change{Kind: ChangeModified, Path: path, Note: "body changed", LeftLine: ad.line, RightLine: bd.line})
+		default:
+			out = append(out, Change{Kind: ChangeModified, Path: path, Old: ad.summary, New: bd.summary, LeftLine: ad.line, RightLine: bd.line})
+		}
+	}
+	for _, bd := range b {
+		if !seen[bd.key] {
+			out = append(out, Change{Kind: ChangeAdded, Path: prefix + bd.key, New: bd.summary, RightLine: bd.line})
+		}
+	}
+	return out
+}
@@ -0,0 +1,176 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareGoDeclarations(t *testing.T) {
+	left := `package demo
+
+import (
+	"fmt"
+	"os"
+)
+
+const Version = "1.0"
+
+type Config struct {
+	Name string
+	Port int
+}
+
+func Run(name string) error {
+	fmt.Println(name)
+	return nil
+}
+
+func (c *Config) Addr() string { return fmt.Sprint(c.Port) }
+
+func old() {}
+`
+	right := `package demo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const Version = "1.1"
+
+type Config struct {
+	Name    string
+	Port    int64
+	Verbose bool
+}
+
+func Run(name string, args ...string) error {
+	fmt.Println(name)
+	return nil
+}
+
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+func helper() {}
+`
+	diff, err := CompareGo(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`- import "os": "os"`,
+		`~ const Version: Version = "1.0" → Version = "1.1"`,
+		`~ type Config.Port: int → int64`,
+		`+ type Config.Verbose: bool`,
+		`~ func Run: func Run(name string) error → func Run(name string, args ...string) error`,
+		`~ method Config.Addr: body changed`,
+		`- func old: func old()`,
+		`+ import "strings": "strings"`,
+		`+ func helper: func helper()`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	lines := map[string][2]int{}
+	for _, c := range diff.Changes {
+		lines[c.Path] = [2]int{c.LeftLine, c.RightLine}
+	}
+	if got := lines["func Run"]; got != [2]int{15, 16} {
+		t.Errorf("func Run lines = %v, want [15 16]", got)
+	}
+	if got := lines["func helper"]; got != [2]int{0, 25} {
+		t.Errorf("func helper lines = %v, want [0 25]", got)
+	}
+}
+
+func TestCompareGoRepeatedAndBlankDeclarations(t *testing.T) {
+	left := `package demo
+
+var _ Runner = (*Job)(nil)
+
+func init() { setup("a") }
+
+func init() { setup("b") }
+`
+	right := `package demo
+
+var _ Runner = (*Task)(nil)
+
+var _ = fmt.Sprint
+
+func init() { setup("a") }
+
+func init() { setup("c") }
+
+func init() { setup("d") }
+`
+	diff, err := CompareGo(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"~ var _ Runner: _ Runner = (*Job)(nil) → _ Runner = (*Task)(nil)",
+		"~ func init#2: body changed",
+		"+ var _: _ = fmt.Sprint",
+		"+ func init#3: func init()",
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareGoTypeChangesBeyondMembers(t *testing.T) {
+	tests := []struct {
+		name, left, right, want string
+	}{
+		{
+			"type parameters",
+			"package demo\n\ntype Set[K any] struct{ m map[K]bool }\n",
+			"package demo\n\ntype Set[K comparable] struct{ m map[K]bool }\n",
+			"~ type Set: [K any] → [K comparable]",
+		},
+		{
+			"field order",
+			"package demo\n\ntype Point struct {\n\tX int\n\tY int\n}\n",
+			"package demo\n\ntype Point struct {\n\tY int\n\tX int\n}\n",
+			"~ type Point: fields reordered",
+		},
+		{
+			"method order",
+			"package demo\n\ntype Store interface {\n\tGet() int\n\tPut(int)\n}\n",
+			"package demo\n\ntype Store interface {\n\tPut(int)\n\tGet() int\n}\n",
+			"~ type Store: methods reordered",
+		},
+	}
+	for _, tt := range tests {
+		diff, err := CompareGo(tt.left, tt.right)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := changeStrings(diff); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompareGoIgnoresFormatting(t *testing.T) {
+	left := "package demo\n\n// Add adds.\nfunc Add(a, b int) int { return a+b }\n"
+	right := "package demo\n\n// Add returns the sum.\nfunc Add(a, b int) int {\n\treturn a + b\n}\n"
+
+	diff, err := CompareGo(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", changeStrings(diff))
+	}
+}
+
+func TestCompareGoInvalid(t *testing.T) {
+	if _, err := CompareGo("package demo\n", "package demo\nfunc {\n"); err == nil {
+		t.Error("expected an error for invalid Go source")
+	}
+}
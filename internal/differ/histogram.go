@@ -0,0 +1,85 @@
+package differ
+
+// maxHistogramChain is the occurrence count above which a line is considered
+// too common to anchor on, matching git's histogram implementation.
+const maxHistogramChain = 64
+
+// histogramMatches implements histogram diff: the common region built around
+// the least frequent line shared by both sides is used as a split point, and
+// the ranges before and after it are diffed recursively. Ranges where every
+// shared line is too common fall back to Myers.
+func histogramMatches(a, b []int, bud *budget) [][2]int {
+	var out [][2]int
+	histogramRange(a, b, 0, len(a), 0, len(b), bud, &out)
+	return out
+}
+
+func histogramRange(a, b []int, aLo, aHi, bLo, bHi int, bud *budget, out *[][2]int) {
+	aLo, aHi, bLo, bHi, suffix := trimCommon(a, b, aLo, aHi, bLo, bHi, out)
+	defer appendSuffix(aHi, bHi, suffix, out)
+
+	if aLo == aHi || bLo == bHi {
+		return
+	}
+
+	startA, startB, length, ok := lowestOccurrenceRegion(a, b, aLo, aHi, bLo, bHi)
+	if !ok {
+		myersRange(a, b, aLo, aHi, bLo, bHi, bud, out)
+		return
+	}
+
+	histogramRange(a, b, aLo, startA, bLo, startB, bud, out)
+	for i := 0; i < length; i++ {
+		*out = append(*out, [2]int{startA + i, startB + i})
+	}
+	histogramRange(a, b, startA+length, aHi, startB+length, bHi, bud, out)
+}
+
+// lowestOccurrenceRegion finds the common region whose rarest line has the
+// fewest occurrences on the left, preferring longer regions on ties.
+func lowestOccurrenceRegion(a, b []int, aLo, aHi, bLo, bHi int) (startA, startB, length int, ok bool) {
+	positions := make(map[int][]int)
+	for i := aLo; i < aHi; i++ {
+		positions[a[i]] = append(positions[a[i]], i)
+	}
+
+	bestCount := maxHistogramChain + 1
+	for j := bLo; j < bHi; {
+		next := j + 1
+		occ := positions[b[j]]
+		if len(occ) == 0 || len(occ) > maxHistogramChain || len(occ) > bestCount {
+			j = next
+			continue
+		}
+		for _, i := range occ {
+			// Extend the match in both directions
+			s, t := i, j
+			for s > aLo && t > bLo && a[s-1] == b[t-1] {
+				s--
+				t--
+			}
+			e, f := i+1, j+1
+			for e < aHi && f < bHi && a[e] == b[f] {
+				e++
+				f++
+			}
+
+			count := len(occ)
+			for k := s; k < e; k++ {
+				if c := len(positions[a[k]]); c < count {
+					count = c
+				}
+			}
+			if count < bestCount || (count == bestCount && e-s > length) {
+				startA, startB, length = s, t, e-s
+				bestCount = count
+				ok = true
+			}
+			if f > next {
+				next = f
+			}
+		}
+		j = next
+	}
+	return startA, startB, length, ok
+}
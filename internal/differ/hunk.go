@@ -0,0 +1,143 @@
+package differ
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+)
+
+// Hunk is a group of nearby changes with surrounding context lines. It is
+// the unit used for hunk navigation, hunk-level merging and patch output.
+type Hunk struct {
+	// ID identifies the hunk by the changes it contains, so it stays the
+	// same when the context size changes or unrelated hunks move it around
+	ID string
+
+	LeftStart  int // first left line number covered; for LeftCount 0, the line before the hunk
+	LeftCount  int
+	RightStart int // first right line number covered; for RightCount 0, the line before the hunk
+	RightCount int
+
+	Lines []DiffLine // context and changed lines, in diff order
+	Start int        // index of Lines[0] in FileDiff.Lines
+	End   int        // index just past the last line in FileDiff.Lines
+}
+
+// isChange reports whether a line counts as a change when grouping hunks
+func (l DiffLine) isChange() bool {
+	return l.Type != DiffEqual && !l.Ignored
+}
+
+// Hunks groups the diff into hunks with up to context equal lines around
+// each change. Changes separated by at most 2*context equal lines share a
+// hunk. Ignored changes never start a hunk but are kept when they fall
+// inside one.
+func (fd *FileDiff) Hunks(context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks []Hunk
+	lines := fd.Lines
+	for i := 0; i < len(lines); {
+		if !lines[i].isChange() {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+
+		// Extend through changes until the gap to the next one is too wide
+		end := i + 1
+		for j := end; j < len(lines); j++ {
+			if lines[j].isChange() {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(lines))
+
+		hunks = append(hunks, fd.newHunk(start, end))
+		i = end
+	}
+
+	assignHunkIDs(hunks)
+	return hunks
+}
+
+// newHunk builds the hunk covering fd.Lines[start:end]
+func (fd *FileDiff) newHunk(start, end int) Hunk {
+	h := Hunk{Lines: fd.Lines[start:end], Start: start, End: end}
+
+	// Line numbers before the hunk, for sides the hunk doesn't touch
+	for i := start - 1; i >= 0 && (h.LeftStart == 0 || h.RightStart == 0); i-- {
+		if h.LeftStart == 0 && fd.Lines[i].LeftLineNum > 0 {
+			h.LeftStart = fd.Lines[i].LeftLineNum
+		}
+		if h.RightStart == 0 && fd.Lines[i].RightLineNum > 0 {
+			h.RightStart = fd.Lines[i].RightLineNum
+		}
+	}
+
+	firstLeft, firstRight := 0, 0
+	for _, line := range h.Lines {
+		if line.Type != DiffInsert {
+			h.LeftCount++
+			if firstLeft == 0 {
+				firstLeft = line.LeftLineNum
+			}
+		}
+		if line.Type != DiffDelete {
+			h.RightCount++
+			if firstRight == 0 {
+				firstRight = line.RightLineNum
+			}
+		}
+	}
+	if firstLeft > 0 {
+		h.LeftStart = firstLeft
+	}
+	if firstRight > 0 {
+		h.RightStart = firstRight
+	}
+	return h
+}
+
+// assignHunkIDs hashes each hunk's changed lines. Hunks with identical
+// changes get an occurrence suffix to keep IDs unique within a diff.
+func assignHunkIDs(hunks []Hunk) {
+	seen := make(map[string]int)
+	for i := range hunks {
+		sum := sha1.New()
+		for _, line := range hunks[i].Lines {
+			if line.Type == DiffEqual {
+				continue
+			}
+			sign := "+"
+			if line.Type == DiffDelete {
+				sign = "-"
+			}
+			sum.Write([]byte(sign + line.Content + "\n"))
+		}
+		id := hex.EncodeToString(sum.Sum(nil))[:12]
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s-%d", id, n)
+		}
+		hunks[i].ID = id
+	}
+}
+
+// HunkAt returns the index of the hunk containing the line at index i,
+// or -1 if the line is outside every hunk
+func HunkAt(hunks []Hunk, i int) int {
+	for k, h := range hunks {
+		if i >= h.Start && i < h.End {
+			return k
+		}
+	}
+	return -1
+}
@@ -0,0 +1,123 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered returns "prefix1\nprefix2\n..." with n lines
+func numbered(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i+1)
+	}
+	return lines
+}
+
+func joinLines(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ---- grouping ---------------------------------------------------------------
+
+func TestHunksNoChanges(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "a\nb\n", "a\nb\n")
+	if hunks := diff.Hunks(3); len(hunks) != 0 {
+		t.Errorf("identical files should have no hunks, got %d", len(hunks))
+	}
+}
+
+func TestHunksSeparateAndMerge(t *testing.T) {
+	left := numbered("line", 30)
+	right := append([]string(nil), left...)
+	right[4] = "changed5"
+	right[24] = "changed25"
+
+	diff := New().CompareStrings("l", "r", joinLines(left), joinLines(right))
+	hunks := diff.Hunks(3)
+	if len(hunks) != 2 {
+		t.Fatalf("distant changes should form 2 hunks, got %d", len(hunks))
+	}
+
+	h := hunks[0]
+	if h.LeftStart != 2 || h.LeftCount != 7 || h.RightStart != 2 || h.RightCount != 7 {
+		t.Errorf("hunk 1 = -%d,%d +%d,%d, want -2,7 +2,7", h.LeftStart, h.LeftCount, h.RightStart, h.RightCount)
+	}
+	if len(h.Lines) != h.End-h.Start {
+		t.Errorf("Lines length %d does not match range [%d,%d)", len(h.Lines), h.Start, h.End)
+	}
+
+	// With a wide context the two changes are close enough to share a hunk
+	if hunks := diff.Hunks(10); len(hunks) != 1 {
+		t.Errorf("context 10 should merge the changes, got %d hunks", len(hunks))
+	}
+}
+
+func TestHunksPureInsertion(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "a\nb\n", "a\nnew\nb\n")
+	hunks := diff.Hunks(0)
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+	h := hunks[0]
+	if h.LeftStart != 1 || h.LeftCount != 0 || h.RightStart != 2 || h.RightCount != 1 {
+		t.Errorf("hunk = -%d,%d +%d,%d, want -1,0 +2,1", h.LeftStart, h.LeftCount, h.RightStart, h.RightCount)
+	}
+}
+
+func TestHunksSkipIgnoredChanges(t *testing.T) {
+	diff := NewWithOptions(Options{IgnoreBlankLines: true}).CompareStrings("l", "r", "a\nb\n", "a\n\nb\n")
+	if hunks := diff.Hunks(3); len(hunks) != 0 {
+		t.Errorf("ignored changes should not form hunks, got %d", len(hunks))
+	}
+}
+
+// ---- IDs --------------------------------------------------------------------
+
+func TestHunkIDsAreStable(t *testing.T) {
+	left := numbered("line", 40)
+	right := append([]string(nil), left...)
+	right[30] = "changed"
+
+	before := New().CompareStrings("l", "r", joinLines(left), joinLines(right)).Hunks(3)
+
+	// An unrelated change earlier in the file shifts the hunk but keeps its ID
+	right2 := append([]string{"header"}, right...)
+	after := New().CompareStrings("l", "r", joinLines(left), joinLines(right2)).Hunks(3)
+	if len(before) != 1 || len(after) != 2 {
+		t.Fatalf("unexpected hunk counts %d, %d", len(before), len(after))
+	}
+	if before[0].ID != after[1].ID {
+		t.Errorf("hunk ID changed from %s to %s", before[0].ID, after[1].ID)
+	}
+	if after[0].ID == after[1].ID {
+		t.Error("different hunks should have different IDs")
+	}
+}
+
+func TestHunkIDsUnique(t *testing.T) {
+	// The same edit in two places produces hunks with identical changes
+	left := numbered("x", 20)
+	left[16] = "x3"
+	right := append([]string(nil), left...)
+	right[2], right[16] = "y", "y"
+
+	hunks := New().CompareStrings("l", "r", joinLines(left), joinLines(right)).Hunks(1)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	if hunks[0].ID == hunks[1].ID {
+		t.Errorf("identical hunks share ID %s", hunks[0].ID)
+	}
+}
+
+func TestHunkAt(t *testing.T) {
+	hunks := []Hunk{{Start: 2, End: 5}, {Start: 9, End: 12}}
+	cases := map[int]int{0: -1, 2: 0, 4: 0, 5: -1, 9: 1, 11: 1, 12: -1}
+	for i, want := range cases {
+		if got := HunkAt(hunks, i); got != want {
+			t.Errorf("HunkAt(%d) = %d, want %d", i, got, want)
+		}
+	}
+}
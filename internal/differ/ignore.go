@@ -0,0 +1,117 @@
+package differ
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// IgnoreRuleKind selects what an ignore rule does with its matches
+type IgnoreRuleKind int
+
+const (
+	IgnoreMask IgnoreRuleKind = iota // matching substrings are masked before comparison
+	IgnoreLine                       // any two lines matching the rule compare equal
+)
+
+// String returns the keyword used for the kind in ignore files
+func (k IgnoreRuleKind) String() string {
+	if k == IgnoreLine {
+		return "line"
+	}
+	return "mask"
+}
+
+// IgnoreRule is a user-defined pattern for volatile content such as
+// timestamps, build IDs or UUIDs
+type IgnoreRule struct {
+	Kind    IgnoreRuleKind
+	Pattern *regexp.Regexp
+}
+
+// String returns the rule in ignore file syntax
+func (r IgnoreRule) String() string {
+	return r.Kind.String() + " " + r.Pattern.String()
+}
+
+// NewIgnoreRule compiles expr into a rule of the given kind
+func NewIgnoreRule(kind IgnoreRuleKind, expr string) (IgnoreRule, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return IgnoreRule{}, fmt.Errorf("invalid ignore pattern %q: %w", expr, err)
+	}
+	return IgnoreRule{Kind: kind, Pattern: re}, nil
+}
+
+// LoadIgnoreRules reads rules from a file. Each non-empty line is either
+// "mask <regex>" or "line <regex>", the keyword and pattern separated by
+// spaces or tabs; lines starting with # are comments.
+func LoadIgnoreRules(path string) ([]IgnoreRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var rules []IgnoreRule
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// The keyword ends at the first run of spaces or tabs
+		keyword, expr := text, ""
+		if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+			keyword, expr = text[:i], strings.TrimLeftFunc(text[i:], unicode.IsSpace)
+		}
+		var kind IgnoreRuleKind
+		switch keyword {
+		case "mask":
+			kind = IgnoreMask
+		case "line":
+			kind = IgnoreLine
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown rule %q (want mask or line)", path, lineNum, keyword)
+		}
+		if expr == "" {
+			return nil, fmt.Errorf("%s:%d: %s rule needs a pattern", path, lineNum, keyword)
+		}
+
+		rule, err := NewIgnoreRule(kind, expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file %s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+// ignoreMarker replaces masked text and whole ignored lines in comparison
+// keys. NUL never appears in text files, so it cannot collide with content.
+const ignoreMarker = "\x00"
+
+// normalize applies the rule to a line's comparison key
+func (r IgnoreRule) normalize(index int) func(string) string {
+	if r.Kind == IgnoreLine {
+		key := ignoreMarker + strconv.Itoa(index)
+		return func(s string) string {
+			if r.Pattern.MatchString(s) {
+				return key
+			}
+			return s
+		}
+	}
+	return func(s string) string {
+		return r.Pattern.ReplaceAllLiteralString(s, ignoreMarker)
+	}
+}
@@ -0,0 +1,128 @@
+package differ
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustRule(t *testing.T, kind IgnoreRuleKind, expr string) IgnoreRule {
+	t.Helper()
+	rule, err := NewIgnoreRule(kind, expr)
+	if err != nil {
+		t.Fatalf("NewIgnoreRule(%q): %v", expr, err)
+	}
+	return rule
+}
+
+// ---- mask rules -------------------------------------------------------------
+
+func TestMaskRuleEqualizesVolatileText(t *testing.T) {
+	left := "build 1a2b3c started\nstatus ok\n"
+	right := "build 9f8e7d started\nstatus ok\n"
+	rule := mustRule(t, IgnoreMask, `[0-9a-f]{6}`)
+
+	diff := NewWithOptions(Options{IgnoreRules: []IgnoreRule{rule}}).CompareStrings("l", "r", left, right)
+	if hasChanges(diff) {
+		t.Fatalf("masked build IDs should compare equal: %+v", diff.Lines)
+	}
+	line := diff.Lines[0]
+	if line.Equalized != EqualizedRule {
+		t.Errorf("expected rule equalization, got %v", line.Equalized)
+	}
+	if line.Content != "build 1a2b3c started" || line.RightText() != "build 9f8e7d started" {
+		t.Errorf("originals not kept: %q / %q", line.Content, line.RightText())
+	}
+}
+
+func TestMaskRuleKeepsOtherChanges(t *testing.T) {
+	rule := mustRule(t, IgnoreMask, `\d{2}:\d{2}:\d{2}`)
+	diff := NewWithOptions(Options{IgnoreRules: []IgnoreRule{rule}}).CompareStrings("l", "r",
+		"10:00:00 started\n", "11:30:00 stopped\n")
+	if !hasChanges(diff) {
+		t.Error("text outside the mask should still be compared")
+	}
+}
+
+// ---- line rules -------------------------------------------------------------
+
+func TestLineRuleEqualizesWholeLines(t *testing.T) {
+	rule := mustRule(t, IgnoreLine, `^# Generated at `)
+	diff := NewWithOptions(Options{IgnoreRules: []IgnoreRule{rule}}).CompareStrings("l", "r",
+		"# Generated at Monday\nkey: 1\n", "# Generated at Friday, by ci\nkey: 1\n")
+	if hasChanges(diff) {
+		t.Fatalf("lines matching a line rule should compare equal: %+v", diff.Lines)
+	}
+	if diff.Lines[0].Equalized != EqualizedRule {
+		t.Errorf("expected rule equalization, got %v", diff.Lines[0].Equalized)
+	}
+}
+
+func TestLineRuleNeedsBothSidesToMatch(t *testing.T) {
+	rule := mustRule(t, IgnoreLine, `^timestamp=`)
+	diff := NewWithOptions(Options{IgnoreRules: []IgnoreRule{rule}}).CompareStrings("l", "r",
+		"timestamp=1\n", "time=1\n")
+	if !hasChanges(diff) {
+		t.Error("a line matching on one side only is a real change")
+	}
+}
+
+func TestRulesCombineWithWhitespace(t *testing.T) {
+	rule := mustRule(t, IgnoreMask, `id=\S+`)
+	opts := Options{Whitespace: WhitespaceIgnoreAll, IgnoreRules: []IgnoreRule{rule}}
+	diff := NewWithOptions(opts).CompareStrings("l", "r", "  id=abc ok\n", "id=xyz   ok\n")
+	if hasChanges(diff) {
+		t.Fatalf("expected lines to compare equal: %+v", diff.Lines)
+	}
+	if got := diff.Lines[0].Equalized; got != EqualizedRule|EqualizedWhitespace {
+		t.Errorf("Equalized = %v, want rule,whitespace", got)
+	}
+}
+
+// ---- ignore files -----------------------------------------------------------
+
+func TestLoadIgnoreRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules")
+	content := "# volatile values\n\nmask [0-9a-f]{8}-[0-9a-f]{4}\nline   ^Date: \nmask\t\\d{4}-\\d\\d-\\d\\d\nline  \t^Server:\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadIgnoreRules(path)
+	if err != nil {
+		t.Fatalf("LoadIgnoreRules: %v", err)
+	}
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 rules, got %d", len(rules))
+	}
+	if rules[0].Kind != IgnoreMask || rules[1].Kind != IgnoreLine || rules[2].Kind != IgnoreMask || rules[3].Kind != IgnoreLine {
+		t.Errorf("unexpected kinds: %v, %v, %v, %v", rules[0].Kind, rules[1].Kind, rules[2].Kind, rules[3].Kind)
+	}
+	for i, want := range []string{"^Date:", `\d{4}-\d\d-\d\d`, "^Server:"} {
+		if got := rules[i+1].Pattern.String(); got != want {
+			t.Errorf("pattern %d = %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestLoadIgnoreRulesErrors(t *testing.T) {
+	cases := map[string]string{
+		"drop foo\n":  "unknown rule",
+		"mask\n":      "needs a pattern",
+		"line ([a-\n": "invalid ignore pattern",
+	}
+	for content, want := range cases {
+		path := filepath.Join(t.TempDir(), "rules")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadIgnoreRules(path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: error = %v, want it to mention %q", content, err, want)
+		}
+		if err != nil && !strings.Contains(err.Error(), ":1:") {
+			t.Errorf("%q: error should carry the line number: %v", content, err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Granularity selects how finely a modified line pair is compared when
+// computing intra-line change spans
+type Granularity int
+
+const (
+	GranularityWord  Granularity = iota // words, whitespace runs and single symbols
+	GranularityChar                     // individual characters
+	GranularityToken                    // source code tokens, across the lines of a changed block
+)
+
+// Granularities lists every granularity in cycling order
+var Granularities = []Granularity{GranularityWord, GranularityChar, GranularityToken}
+
+// String returns the name used on the command line and in the UI
+func (g Granularity) String() string {
+	switch g {
+	case GranularityChar:
+		return "char"
+	case GranularityToken:
+		return "token"
+	default:
+		return "word"
+	}
+}
+
+// Next returns the granularity that follows g in cycling order
+func (g Granularity) Next() Granularity {
+	for i, gr := range Granularities {
+		if gr == g {
+			return Granularities[(i+1)%len(Granularities)]
+		}
+	}
+	return GranularityWord
+}
+
+// ParseGranularity converts a name such as "token" into a Granularity
+func ParseGranularity(name string) (Granularity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "word", "words":
+		return GranularityWord, nil
+	case "char", "character", "characters":
+		return GranularityChar, nil
+	case "token", "tokens":
+		return GranularityToken, nil
+	}
+	return GranularityWord, fmt.Errorf("unknown granularity %q (want word, char or token)", name)
+}
+
+// Span marks a changed byte range [Start, End) within a line's content
+type Span struct {
+	Start int
+	End   int
+}
+
+// maxInlineBytes bounds the line length that gets an intra-line diff; longer
+// pairs are marked as changed in full to keep rendering responsive.
+const maxInlineBytes = 8192
+
+// InlineDiff compares two versions of a line and returns the byte ranges
+// that changed in each.
+func InlineDiff(left, right string, g Granularity) ([]Span, []Span) {
+	if left == right {
+		return nil, nil
+	}
+	if len(left)+len(right) > maxInlineBytes {
+		return wholeSpan(left), wholeSpan(right)
+	}
+
+	leftTokens := tokenize(left, g)
+	rightTokens := tokenize(right, g)
+	a, b := internLines(tokenTexts(left, leftTokens), tokenTexts(right, rightTokens), identity)
+	matches := myersMatches(a, b)
+
+	leftMatched := make([]bool, len(leftTokens))
+	rightMatched := make([]bool, len(rightTokens))
+	for _, mt := range matches {
+		leftMatched[mt[0]] = true
+		rightMatched[mt[1]] = true
+	}
+
+	return changedSpans(left, leftTokens, leftMatched, g), changedSpans(right, rightTokens, rightMatched, g)
+}
+
+func wholeSpan(s string) []Span {
+	if s == "" {
+		return nil
+	}
+	return []Span{{Start: 0, End: len(s)}}
+}
+
+// tokenize splits s into tokens and returns their byte ranges
+func tokenize(s string, g Granularity) []Span {
+	var tokens []Span
+	if g == GranularityToken {
+		for _, tok := range TokenizeCode(s) {
+			tokens = append(tokens, tok.Span)
+		}
+		return tokens
+	}
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		if g == GranularityWord {
+			class := runeClass(r)
+			if class != classSymbol {
+				for end < len(s) {
+					next, nextSize := utf8.DecodeRuneInString(s[end:])
+					if runeClass(next) != class {
+						break
+					}
+					end += nextSize
+				}
+			}
+		}
+		tokens = append(tokens, Span{Start: i, End: end})
+		i = end
+	}
+	return tokens
+}
+
+const (
+	classWord = iota
+	classSpace
+	classSymbol
+)
+
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return classWord
+	case unicode.IsSpace(r):
+		return classSpace
+	default:
+		return classSymbol
+	}
+}
+
+func tokenTexts(s string, tokens []Span) []string {
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = s[tok.Start:tok.End]
+	}
+	return texts
+}
+
+// changedSpans merges unmatched tokens into contiguous spans. In word and
+// token mode, changed tokens separated only by whitespace are joined into
+// one span so a rewritten phrase reads as a single change.
+func changedSpans(s string, tokens []Span, matched []bool, g Granularity) []Span {
+	var spans []Span
+	for i, tok := range tokens {
+		if matched[i] {
+			continue
+		}
+		if n := len(spans); n > 0 {
+			last := &spans[n-1]
+			if last.End == tok.Start {
+				last.End = tok.End
+				continue
+			}
+			if g != GranularityChar && onlySpace(s[last.End:tok.Start]) {
+				last.End = tok.End
+				continue
+			}
+		}
+		spans = append(spans, tok)
+	}
+	return spans
+}
+
+func onlySpace(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// annotateInline fills in the intra-line spans of every modified pair, or
+// in token mode of every changed line
+func (d *Differ) annotateInline(lines []DiffLine) {
+	if d.Granularity == GranularityToken {
+		annotateTokens(lines)
+		return
+	}
+	for i := range lines {
+		if lines[i].Type != DiffDelete || lines[i].Pair == 0 {
+			continue
+		}
+		j := lines[i].Pair - 1
+		lines[i].Spans, lines[j].Spans = InlineDiff(lines[i].Content, lines[j].Content, d.Granularity)
+	}
+}
@@ -0,0 +1,121 @@
+package differ
+
+import "testing"
+
+// ---- helpers ----------------------------------------------------------------
+
+func spanTexts(s string, spans []Span) []string {
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = s[sp.Start:sp.End]
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ---- InlineDiff -------------------------------------------------------------
+
+func TestInlineDiffWord(t *testing.T) {
+	left := "  timeout: 30 # seconds"
+	right := "  timeout: 45 # seconds"
+	l, r := InlineDiff(left, right, GranularityWord)
+
+	if got := spanTexts(left, l); !equalStrings(got, []string{"30"}) {
+		t.Errorf("left spans = %q, want [\"30\"]", got)
+	}
+	if got := spanTexts(right, r); !equalStrings(got, []string{"45"}) {
+		t.Errorf("right spans = %q, want [\"45\"]", got)
+	}
+}
+
+func TestInlineDiffChar(t *testing.T) {
+	left := "replicas: 3"
+	right := "replicas: 31"
+	l, r := InlineDiff(left, right, GranularityChar)
+
+	if len(l) != 0 {
+		t.Errorf("left should have no changed spans, got %q", spanTexts(left, l))
+	}
+	if got := spanTexts(right, r); !equalStrings(got, []string{"1"}) {
+		t.Errorf("right spans = %q, want [\"1\"]", got)
+	}
+}
+
+func TestInlineDiffJoinsPhrase(t *testing.T) {
+	left := "the quick brown fox"
+	right := "the slow red fox"
+	l, _ := InlineDiff(left, right, GranularityWord)
+
+	if got := spanTexts(left, l); !equalStrings(got, []string{"quick brown"}) {
+		t.Errorf("left spans = %q, want a single \"quick brown\" span", got)
+	}
+}
+
+func TestInlineDiffIdentical(t *testing.T) {
+	l, r := InlineDiff("same", "same", GranularityWord)
+	if l != nil || r != nil {
+		t.Errorf("identical lines should have no spans, got %v %v", l, r)
+	}
+}
+
+func TestInlineDiffMultibyte(t *testing.T) {
+	left := "naïve café"
+	right := "naïve cafe"
+	l, r := InlineDiff(left, right, GranularityChar)
+
+	if got := spanTexts(left, l); !equalStrings(got, []string{"é"}) {
+		t.Errorf("left spans = %q, want [\"é\"]", got)
+	}
+	if got := spanTexts(right, r); !equalStrings(got, []string{"e"}) {
+		t.Errorf("right spans = %q, want [\"e\"]", got)
+	}
+}
+
+// ---- spans on diffs ---------------------------------------------------------
+
+func TestModifiedPairsGetSpans(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "a\nport: 8080\nc\n", "a\nport: 9090\nc\n")
+
+	for _, line := range diff.Lines {
+		switch line.Type {
+		case DiffEqual:
+			if line.Spans != nil {
+				t.Errorf("equal line %q should have no spans", line.Content)
+			}
+		case DiffDelete, DiffInsert:
+			if got := spanTexts(line.Content, line.Spans); len(got) != 1 || (got[0] != "8080" && got[0] != "9090") {
+				t.Errorf("line %q: unexpected spans %q", line.Content, got)
+			}
+		}
+	}
+
+	rows := BuildSideBySideRows(diff.Lines)
+	for _, row := range rows {
+		if row.Type != SBSModified {
+			continue
+		}
+		if len(row.LeftSpans) == 0 || len(row.RightSpans) == 0 {
+			t.Errorf("modified row should carry spans on both sides: %+v", row)
+		}
+	}
+}
+
+func TestUnpairedLinesHaveNoSpans(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "a\nb\nc\n", "a\nc\n")
+	for _, line := range diff.Lines {
+		if line.Spans != nil {
+			t.Errorf("line %q is not part of a modified pair but has spans", line.Content)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package differ
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONOptions configures CompareJSON
+type JSONOptions struct {
+	// ArrayKeys are object fields that identify array elements, tried in
+	// order. An array whose elements all have a distinct scalar value for
+	// one of them, on both sides, is matched element by element on that
+	// value instead of by position, so reordering is not a change.
+	ArrayKeys []string
+}
+
+// DefaultJSONOptions matches array elements on the usual identity fields
+func DefaultJSONOptions() JSONOptions {
+	return JSONOptions{ArrayKeys: []string{"id", "name", "key"}}
+}
+
+// CompareJSON compares two JSON documents by value and reports changes by
+// path, such as "$.servers[2].port: 8080 → 9090". Object key order,
+// whitespace and number formatting are ignored. An empty side stands for a
+// missing file. It fails if either side is not valid JSON.
+func CompareJSON(left, right string, opts JSONOptions) (*StructuredDiff, error) {
+	a, err := parseJSON(left)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing left JSON: %w", err)
+	}
+	b, err := parseJSON(right)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing right JSON: %w", err)
+	}
+
+	c := &treeComparer{keys: opts.ArrayKeys, diff: &StructuredDiff{Format: "JSON"}}
+	c.compareRoots("$", a, b)
+	return c.diff, nil
+}
+
+// parseJSON decodes a single JSON value, keeping numbers as written. An
+// empty or blank document decodes to nil.
+func parseJSON(content string) (any, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	if v == nil {
+		return topNull{}, nil
+	}
+	return v, nil
+}
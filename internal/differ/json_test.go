@@ -0,0 +1,88 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func changeStrings(diff *StructuredDiff) []string {
+	out := make([]string, len(diff.Changes))
+	for i, c := range diff.Changes {
+		out[i] = c.Kind.Symbol() + " " + c.String()
+	}
+	return out
+}
+
+func TestCompareJSONIgnoresFormattingAndKeyOrder(t *testing.T) {
+	left := `{"name": "svc", "version": 1.0, "tags": ["a", "b"]}`
+	right := "{\n  \"tags\": [\"a\", \"b\"],\n  \"version\": 1,\n  \"name\": \"svc\"\n}\n"
+
+	diff, err := CompareJSON(left, right, DefaultJSONOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", changeStrings(diff))
+	}
+}
+
+func TestCompareJSONReportsPaths(t *testing.T) {
+	left := `{"servers": [{"host": "a", "port": 80}, {"host": "b", "port": 80}, {"host": "c", "port": 8080}], "debug": true}`
+	right := `{"servers": [{"host": "a", "port": 80}, {"host": "b", "port": 80}, {"host": "c", "port": 9090}], "mode": "prod"}`
+
+	diff, err := CompareJSON(left, right, JSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`- $.debug: true`,
+		`+ $.mode: "prod"`,
+		`~ $.servers[2].port: 8080 → 9090`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareJSONMatchesArraysByKey(t *testing.T) {
+	left := `[{"name": "web", "port": 80}, {"name": "db", "port": 5432}, {"name": "old", "port": 1}]`
+	right := `[{"name": "db", "port": 5433}, {"name": "web", "port": 80}, {"name": "cache", "port": 6379}]`
+
+	diff, err := CompareJSON(left, right, JSONOptions{ArrayKeys: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`~ $[name="db"].port: 5432 → 5433`,
+		`- $[name="old"]: {"name":"old","port":1}`,
+		`+ $[name="cache"]: {"name":"cache","port":6379}`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Without the key the same arrays are compared by position
+	diff, _ = CompareJSON(left, right, JSONOptions{})
+	if len(diff.Changes) < 4 {
+		t.Errorf("positional comparison should see every element change, got %v", changeStrings(diff))
+	}
+}
+
+func TestCompareJSONMissingSide(t *testing.T) {
+	diff, err := CompareJSON("", `{"a": 1}`, DefaultJSONOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changeStrings(diff); len(got) != 1 || got[0] != `+ $: {"a":1}` {
+		t.Errorf("unexpected changes %v", got)
+	}
+}
+
+func TestCompareJSONInvalid(t *testing.T) {
+	if _, err := CompareJSON(`{"a": 1}`, `{"a": }`, DefaultJSONOptions()); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+	if _, err := CompareJSON(`{"a": 1} {"b": 2}`, `{}`, DefaultJSONOptions()); err == nil {
+		t.Error("expected an error for trailing data")
+	}
+}
@@ -0,0 +1,415 @@
+package differ
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyValueFormat is a flavour of key/value configuration file
+type KeyValueFormat int
+
+const (
+	FormatINI        KeyValueFormat = iota // [section] headers, key=value or key: value
+	FormatEnv                              // KEY=value, optionally exported and quoted
+	FormatProperties                       // Java properties: key=value, key:value or key value
+)
+
+// String returns the format's display name
+func (f KeyValueFormat) String() string {
+	switch f {
+	case FormatEnv:
+		return ".env"
+	case FormatProperties:
+		return "Properties"
+	default:
+		return "INI"
+	}
+}
+
+// KeyValueOptions configures CompareKeyValue
+type KeyValueOptions struct {
+	// MaskSecrets hides the values of keys that look like they hold
+	// passwords, tokens or keys, and of URLs with a password in them,
+	// reporting only that they changed. It masks the structural summary
+	// and its export alone: the line diff, patch exports and merges still
+	// show and write the files as they are.
+	MaskSecrets bool
+}
+
+// maskedValue stands in for a secret value when MaskSecrets is set, and
+// secretChanged notes a change between two of them
+const (
+	maskedValue   = "********"
+	secretChanged = "secret changed"
+)
+
+// CompareKeyValue compares two key/value configuration files by key rather
+// than by line, so that moving a key or reformatting a line is not a
+// change. Changes are grouped by section: changed and removed keys of each
+// left section in order, then added keys, with the sections only on the
+// right last. Paths read "[section] key", or just "key" outside any
+// section. A later value for a key replaces an earlier one.
+func CompareKeyValue(left, right string, format KeyValueFormat, opts KeyValueOptions) (*StructuredDiff, error) {
+	a, err := parseKeyValue(left, format)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing left %s file: %w", format, err)
+	}
+	b, err := parseKeyValue(right, format)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing right %s file: %w", format, err)
+	}
+
+	diff := &StructuredDiff{Format: format.String()}
+	for _, sa := range a.sections {
+		sb := b.byName[sa.name]
+		if sb == nil {
+			diff.Changes = append(diff.Changes, sa.whole(ChangeRemoved, opts)...)
+			continue
+		}
+		diff.Changes = append(diff.Changes, compareSections(sa, sb, opts)...)
+	}
+	for _, sb := range b.sections {
+		if a.byName[sb.name] == nil {
+			diff.Changes = append(diff.Changes, sb.whole(ChangeAdded, opts)...)
+		}
+	}
+	for _, c := range diff.Changes {
+		if c.Old == maskedValue || c.New == maskedValue || c.Note == secretChanged {
+			diff.Masked = true
+			break
+		}
+	}
+	return diff, nil
+}
+
+// kvDoc is a parsed key/value file. Keys outside any section, and all keys
+// of formats without sections, belong to the section named "".
+type kvDoc struct {
+	sections []*kvSection
+	byName   map[string]*kvSection
+}
+
+type kvSection struct {
+	name    string
+	line    int
+	entries []kvEntry
+	index   map[string]int
+}
+
+type kvEntry struct {
+	key, value string
+	line       int
+}
+
+func newKVDoc() *kvDoc {
+	doc := &kvDoc{byName: make(map[string]*kvSection)}
+	doc.section("", 0)
+	return doc
+}
+
+// section returns the named section, adding it if new; a repeated section
+// header continues the earlier section
+func (d *kvDoc) section(name string, line int) *kvSection {
+	if s := d.byName[name]; s != nil {
+		return s
+	}
+	s := &kvSection{name: name, line: line, index: make(map[string]int)}
+	d.sections = append(d.sections, s)
+	d.byName[name] = s
+	return s
+}
+
+func (s *kvSection) set(key, value string, line int) {
+	if i, ok := s.index[key]; ok {
+		s.entries[i].value, s.entries[i].line = value, line
+		return
+	}
+	s.index[key] = len(s.entries)
+	s.entries = append(s.entries, kvEntry{key: key, value: value, line: line})
+}
+
+func (s *kvSection) path(key string) string {
+	if s.name == "" {
+		return key
+	}
+	return "[" + s.name + "] " + key
+}
+
+// whole reports every key of a section found on one side only, or the
+// section itself when it has no keys
+func (s *kvSection) whole(kind ChangeKind, opts KeyValueOptions) []Change {
+	if len(s.entries) == 0 {
+		if s.name == "" {
+			return nil
+		}
+		c := Change{Kind: kind, Path: "[" + s.name + "]"}
+		if kind == ChangeAdded {
+			c.New, c.RightLine = "(empty section)", s.line
+		} else {
+			c.Old, c.LeftLine = "(empty section)", s.line
+		}
+		return []Change{c}
+	}
+	var out []Change
+	for _, e := range s.entries {
+		c := Change{Kind: kind, Path: s.path(e.key)}
+		if kind == ChangeAdded {
+			c.New, c.RightLine = kvValueText(e, opts), e.line
+		} else {
+			c.Old, c.LeftLine = kvValueText(e, opts), e.line
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// compareSections compares the keys of a section present on both sides
+func compareSections(sa, sb *kvSection, opts KeyValueOptions) []Change {
+	var out []Change
+	for _, ea := range sa.entries {
+		i, ok := sb.index[ea.key]
+		if !ok {
+			out = append(out, Change{Kind: ChangeRemoved, Path: sa.path(ea.key), Old: kvValueText(ea, opts), LeftLine: ea.line})
+			continue
+		}
+		eb := sb.entries[i]
+		if ea.value == eb.value {
+			continue
+		}
+		c := Change{Kind: ChangeModified, Path: sa.path(ea.key), Old: kvValueText(ea, opts), New: kvValueText(eb, opts), LeftLine: ea.line, RightLine: eb.line}
+		if c.Old == c.New {
+			// Both sides are masked
+			c.Old, c.New, c.Note = "", "", secretChanged
+		}
+		out = append(out, c)
+	}
+	for _, eb := range sb.entries {
+		if _, ok := sa.index[eb.key]; !ok {
+			out = append(out, Change{Kind: ChangeAdded, Path: sb.path(eb.key), New: kvValueText(eb, opts), RightLine: eb.line})
+		}
+	}
+	return out
+}
+
+// kvValueText renders an entry's value for display, masked if it looks
+// secret and opts ask for that
+func kvValueText(e kvEntry, opts KeyValueOptions) string {
+	if opts.MaskSecrets && isSecret(e.key, e.value) {
+		return maskedValue
+	}
+	return valueText(e.value)
+}
+
+// secretKeyWords are fragments of key names that usually hold credentials,
+// matched case-insensitively with separators removed
+var secretKeyWords = []string{
+	"password", "passwd", "passphrase", "secret", "token", "apikey",
+	"accesskey", "privatekey", "credential",
+}
+
+// isSecret reports whether a key's value looks like a credential: by the
+// key's name, or because the value is a URL carrying a password
+func isSecret(key, value string) bool {
+	name := strings.ToLower(key)
+	name = strings.NewReplacer("_", "", "-", "", ".", "").Replace(name)
+	for _, w := range secretKeyWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	if _, rest, ok := strings.Cut(value, "://"); ok {
+		if userinfo, _, ok := strings.Cut(rest, "@"); ok && strings.Contains(userinfo, ":") && !strings.Contains(userinfo, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseKeyValue parses content in the given format
+func parseKeyValue(content string, format KeyValueFormat) (*kvDoc, error) {
+	lines := strings.Split(strings.TrimPrefix(content, "\ufeff"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	switch format {
+	case FormatEnv:
+		return parseEnv(lines)
+	case FormatProperties:
+		return parseProperties(lines), nil
+	default:
+		return parseINI(lines)
+	}
+}
+
+// parseINI reads [section] headers and key=value or key: value lines.
+// Lines starting with ; or # are comments, and a key on its own has an
+// empty value.
+func parseINI(lines []string) (*kvDoc, error) {
+	doc := newKVDoc()
+	section := doc.byName[""]
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", i+1)
+			}
+			section = doc.section(strings.TrimSpace(line[1:len(line)-1]), i+1)
+			continue
+		}
+		key, value := line, ""
+		if sep := strings.IndexAny(line, "=:"); sep >= 0 {
+			key, value = line[:sep], line[sep+1:]
+		}
+		section.set(strings.TrimSpace(key), strings.TrimSpace(value), i+1)
+	}
+	return doc, nil
+}
+
+// parseEnv reads KEY=value lines with an optional "export" prefix. Values
+// may be single-quoted (literal), double-quoted (with escapes, possibly
+// spanning lines) or bare, where " #" starts a comment.
+func parseEnv(lines []string) (*kvDoc, error) {
+	doc := newKVDoc()
+	vars := doc.byName[""]
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		start := i + 1
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=value", start)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", start)
+			}
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			text := value[1:]
+			for closingQuote(text) < 0 && i+1 < len(lines) {
+				i++
+				text += "\n" + lines[i]
+			}
+			end := closingQuote(text)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", start)
+			}
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(text[:end])
+		default:
+			if c := strings.Index(value, " #"); c >= 0 {
+				value = strings.TrimSpace(value[:c])
+			}
+		}
+		vars.set(key, value, start)
+	}
+	return doc, nil
+}
+
+// closingQuote returns the index of the first unescaped double quote in s,
+// or -1
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseProperties reads Java properties. A line ending in an odd number of
+// backslashes continues on the next; the key ends at the first unescaped
+// '=', ':' or whitespace; lines starting with # or ! are comments.
+func parseProperties(lines []string) *kvDoc {
+	doc := newKVDoc()
+	props := doc.byName[""]
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		start := i + 1
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+
+		end := len(line)
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if strings.IndexByte("=: \t\f", line[j]) >= 0 {
+				end = j
+				break
+			}
+		}
+		key, value := line[:end], strings.TrimLeft(line[end:], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+		props.set(unescapeProperty(key), unescapeProperty(value), start)
+	}
+	return doc
+}
+
+// continued reports whether a properties line ends in an odd number of
+// backslashes
+func continued(line string) bool {
+	n := 0
+	for n < len(line) && line[len(line)-1-n] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+// unescapeProperty resolves the escapes of a properties key or value
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
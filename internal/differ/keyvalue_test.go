@@ -0,0 +1,96 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareINIBySection(t *testing.T) {
+	left := "; app settings\nname = demo\n\n[database]\nhost=localhost\nport=5432\n\n[legacy]\nold_setting=true\n"
+	right := "name=demo\n[database]\nport = 5433\nhost = localhost\npool: 10\n\n[cache]\n"
+
+	diff, err := CompareKeyValue(left, right, FormatINI, KeyValueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`~ [database] port: "5432" → "5433"`,
+		`+ [database] pool: "10"`,
+		`- [legacy] old_setting: "true"`,
+		`+ [cache]: (empty section)`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if c := diff.Changes[0]; c.LeftLine != 6 || c.RightLine != 3 {
+		t.Errorf("port lines = %d, %d, want 6, 3", c.LeftLine, c.RightLine)
+	}
+}
+
+func TestCompareEnvQuoting(t *testing.T) {
+	left := "# env\nexport APP_ENV=prod\nGREETING=\"hello\\nworld\"\nLITERAL='a $b'\nPORT=80 # web\n"
+	right := "APP_ENV=\"prod\"\nGREETING=\"hello\nworld\"\nLITERAL=a $b\nPORT=8080\n"
+
+	diff, err := CompareKeyValue(left, right, FormatEnv, KeyValueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`~ PORT: "80" → "8080"`}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := CompareKeyValue("A=1\n", "just text\n", FormatEnv, KeyValueOptions{}); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func TestCompareProperties(t *testing.T) {
+	left := "! comment\ndb.url = jdbc:h2:mem\ngreeting : caf\\u00e9\nlist = a, \\\n       b\n"
+	right := "greeting=café\ndb.url jdbc:h2:file\nlist=a, b\n"
+
+	diff, err := CompareKeyValue(left, right, FormatProperties, KeyValueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`~ db.url: "jdbc:h2:mem" → "jdbc:h2:file"`}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompareKeyValueMasksSecrets(t *testing.T) {
+	left := "DB_PASSWORD=hunter2\nAPI_TOKEN=abc\nDATABASE_URL=postgres://app:pw1@db/app\nLOG_LEVEL=info\n"
+	right := "DB_PASSWORD=hunter3\nDATABASE_URL=postgres://app:pw2@db/app\nLOG_LEVEL=debug\nSTRIPE_SECRET_KEY=sk_live\n"
+
+	diff, err := CompareKeyValue(left, right, FormatEnv, KeyValueOptions{MaskSecrets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"~ DB_PASSWORD: secret changed",
+		"- API_TOKEN: ********",
+		"~ DATABASE_URL: secret changed",
+		`~ LOG_LEVEL: "info" → "debug"`,
+		"+ STRIPE_SECRET_KEY: ********",
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, c := range diff.Changes {
+		if strings.Contains(c.String(), "hunter") || strings.Contains(c.String(), "pw1") {
+			t.Errorf("secret leaked: %s", c)
+		}
+	}
+	if !diff.Masked {
+		t.Error("expected the diff to be marked as masked")
+	}
+
+	diff, err = CompareKeyValue("LOG_LEVEL=info\n", "LOG_LEVEL=debug\n", FormatEnv, KeyValueOptions{MaskSecrets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Masked {
+		t.Error("nothing was masked, but the diff is marked as masked")
+	}
+}
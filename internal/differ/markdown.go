@@ -0,0 +1,600 @@
+package differ
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minSectionSimilarity is how alike a removed and an added section under the
+// same parent must be, by title or by content, to be taken as one renamed
+// section
+const minSectionSimilarity = 0.6
+
+// minItemSimilarity is how alike a removed and an added list item of a
+// section must be to be reported as one edited item
+const minItemSimilarity = 0.6
+
+// SectionChange is a Markdown section that differs between the files
+type SectionChange struct {
+	Kind      ChangeKind // ChangeModified covers renamed, moved and edited
+	Path      string     // titles of the section and its parents, " > " separated
+	OldTitle  string     // title in the left file when renamed
+	Moved     bool
+	LeftLine  int // 1-based line of the heading in each file, 0 if absent
+	RightLine int
+}
+
+// Outline lists the sections of two Markdown documents that differ; it is
+// the Detail of their StructuredDiff
+type Outline []SectionChange
+
+// Counts returns the number of sections added, removed and changed, and of
+// the changed ones, how many were moved and how many renamed
+func (o Outline) Counts() (added, removed, changed, moved, renamed int) {
+	for _, sc := range o {
+		switch sc.Kind {
+		case ChangeAdded:
+			added++
+		case ChangeRemoved:
+			removed++
+		default:
+			changed++
+		}
+		if sc.Moved {
+			moved++
+		}
+		if sc.OldTitle != "" {
+			renamed++
+		}
+	}
+	return added, removed, changed, moved, renamed
+}
+
+// Summary describes the sections by kind of change
+func (o Outline) Summary() string {
+	added, removed, changed, moved, renamed := o.Counts()
+	summary := fmt.Sprintf("Sections: %d added, %d removed, %d changed", added, removed, changed)
+	if moved+renamed > 0 {
+		summary += fmt.Sprintf(" (%d moved, %d renamed)", moved, renamed)
+	}
+	return summary
+}
+
+// CompareMarkdown compares two Markdown documents by their heading outline.
+// Sections are matched by title under matched parents, so that sections
+// added, removed, renamed (told apart by similar titles or content) or
+// moved to another place in the outline are reported as such rather than
+// as lines. Within each matched section, list items and table rows are
+// compared item by item and other text as a whole. The result's Detail is
+// an Outline of the sections that differ; its Changes describe every
+// difference, section by section, with paths such as
+// "Configuration > Database > list".
+func CompareMarkdown(left, right string) *StructuredDiff {
+	a, b := parseMarkdown(left), parseMarkdown(right)
+	m := &mdMatcher{a: a, b: b, pairs: make(map[int]int), rpairs: make(map[int]int), moved: make(map[int]bool), outline: Outline{}}
+	m.pair(0, 0)
+	m.matchChildren(0, 0)
+	m.matchMoves()
+	m.markReordered()
+
+	diff := &StructuredDiff{Format: "Markdown"}
+	m.compareSection(diff, 0, 0)
+	for i := 1; i < len(a); i++ {
+		j, ok := m.pairs[i]
+		if !ok {
+			s := a[i]
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeRemoved, Path: a.path(i), Old: s.heading(), LeftLine: s.line})
+			m.outline = append(m.outline, SectionChange{Kind: ChangeRemoved, Path: a.path(i), LeftLine: s.line})
+			continue
+		}
+		m.compareSection(diff, i, j)
+	}
+	for j := 1; j < len(b); j++ {
+		if _, ok := m.rpairs[j]; !ok {
+			s := b[j]
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeAdded, Path: b.path(j), New: s.heading(), RightLine: s.line})
+			m.outline = append(m.outline, SectionChange{Kind: ChangeAdded, Path: b.path(j), RightLine: s.line})
+		}
+	}
+	diff.Detail = m.outline
+	return diff
+}
+
+// markdownComparator is the built-in "markdown" comparator
+type markdownComparator struct{}
+
+func (markdownComparator) Name() string { return "markdown" }
+
+func (markdownComparator) Compare(ctx context.Context, left, right string) (*StructuredDiff, error) {
+	return uncancellable(func(l, r string) (*StructuredDiff, error) { return CompareMarkdown(l, r), nil })(ctx, left, right)
+}
+
+func (c markdownComparator) CompareOutline(ctx context.Context, left, right string) (Outline, error) {
+	diff, err := c.Compare(ctx, left, right)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Detail.(Outline), nil
+}
+
+// mdSection is a heading and the content up to the next heading. Section 0
+// of a document is the text before its first heading.
+type mdSection struct {
+	level  int
+	title  string
+	line   int
+	parent int
+	number string // position in the outline, e.g. "2.3"
+
+	text   []mdLine // paragraphs, code and other lines, whitespace collapsed
+	items  []mdLine // list items without their markers
+	tables []mdTable
+}
+
+type mdLine struct {
+	text string
+	line int
+}
+
+type mdTable struct {
+	header []string
+	rows   []mdRow
+}
+
+type mdRow struct {
+	cells []string
+	line  int
+}
+
+func (s *mdSection) heading() string {
+	return strings.Repeat("#", s.level) + " " + s.title
+}
+
+// content is the section's own text, for judging renames
+func (s *mdSection) content() string {
+	var parts []string
+	for _, l := range s.text {
+		parts = append(parts, l.text)
+	}
+	for _, l := range s.items {
+		parts = append(parts, l.text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+type mdDoc []*mdSection
+
+var (
+	atxHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextH1     = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	setextH2     = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	listItem     = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+	tableDivider = regexp.MustCompile(`^:?-+:?$`)
+)
+
+// parseMarkdown splits content into sections by ATX (#) and setext
+// (underlined) headings, outside fenced code blocks
+func parseMarkdown(content string) mdDoc {
+	doc := mdDoc{{parent: -1}}
+	cur := doc[0]
+	stack := []int{0} // open sections, innermost last
+	var fence string
+	var table *mdTable
+	lastText := -1 // index in cur.text of the previous line, if it was text
+
+	startSection := func(level int, title string, line int) {
+		for len(stack) > 1 && doc[stack[len(stack)-1]].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		cur = &mdSection{level: level, title: strings.TrimSpace(title), line: line, parent: stack[len(stack)-1]}
+		doc = append(doc, cur)
+		stack = append(stack, len(doc)-1)
+	}
+
+	for n, raw := range strings.Split(content, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		trimmed := strings.TrimSpace(raw)
+		line := n + 1
+		wasText := lastText
+		lastText = -1
+		if trimmed == "" {
+			table = nil
+			continue
+		}
+
+		if fence != "" || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			switch {
+			case fence == "":
+				fence = trimmed[:3]
+			case strings.HasPrefix(trimmed, fence):
+				fence = ""
+			}
+			cur.text = append(cur.text, mdLine{text: trimmed, line: line})
+			continue
+		}
+
+		if m := atxHeading.FindStringSubmatch(raw); m != nil {
+			table = nil
+			startSection(len(m[1]), m[2], line)
+			continue
+		}
+		if wasText >= 0 && (setextH1.MatchString(raw) || setextH2.MatchString(raw)) {
+			// The text line before the underline is the heading
+			title := cur.text[wasText]
+			cur.text = cur.text[:wasText]
+			level := 2
+			if setextH1.MatchString(raw) {
+				level = 1
+			}
+			startSection(level, title.text, title.line)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			cells := tableCells(trimmed)
+			switch {
+			case table == nil:
+				cur.tables = append(cur.tables, mdTable{header: cells})
+				table = &cur.tables[len(cur.tables)-1]
+			case isTableDivider(cells):
+			default:
+				table.rows = append(table.rows, mdRow{cells: cells, line: line})
+			}
+			continue
+		}
+		table = nil
+
+		if m := listItem.FindStringSubmatch(raw); m != nil {
+			cur.items = append(cur.items, mdLine{text: strings.Join(strings.Fields(m[1]), " "), line: line})
+			continue
+		}
+		cur.text = append(cur.text, mdLine{text: strings.Join(strings.Fields(trimmed), " "), line: line})
+		lastText = len(cur.text) - 1
+	}
+
+	doc.number()
+	return doc
+}
+
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func isTableDivider(cells []string) bool {
+	for _, c := range cells {
+		if !tableDivider.MatchString(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// number assigns outline numbers such as "2.3" from each section's position
+// among its parent's children
+func (d mdDoc) number() {
+	count := make(map[int]int)
+	for i := 1; i < len(d); i++ {
+		p := d[i].parent
+		count[p]++
+		d[i].number = fmt.Sprint(count[p])
+		if p > 0 {
+			d[i].number = d[p].number + "." + d[i].number
+		}
+	}
+}
+
+func (d mdDoc) children(i int) []int {
+	var out []int
+	for j := i + 1; j < len(d); j++ {
+		if d[j].parent == i {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// isTitle reports whether section i is the document's title: its only
+// top-level heading, with everything else nested under it. Paths leave it
+// out, as every section would repeat it.
+func (d mdDoc) isTitle(i int) bool {
+	return i > 0 && d[i].parent == 0 && len(d.children(0)) == 1 && len(d.children(i)) > 0
+}
+
+// path names section i by its title and those of its parents
+func (d mdDoc) path(i int) string {
+	if i == 0 {
+		return "(preamble)"
+	}
+	var titles []string
+	for j := i; j > 0; j = d[j].parent {
+		if j == i || !d.isTitle(j) {
+			titles = append(titles, d[j].title)
+		}
+	}
+	for l, r := 0, len(titles)-1; l < r; l, r = l+1, r-1 {
+		titles[l], titles[r] = titles[r], titles[l]
+	}
+	return strings.Join(titles, " > ")
+}
+
+// mdMatcher pairs the sections of two documents
+type mdMatcher struct {
+	a, b          mdDoc
+	pairs, rpairs map[int]int // left to right and back
+	moved         map[int]bool
+	outline       Outline // sections that differ, as compareSection finds them
+}
+
+func (m *mdMatcher) pair(i, j int) {
+	m.pairs[i], m.rpairs[j] = j, i
+}
+
+// matchChildren pairs the children of the matched sections pa and pb by
+// title, then leftovers that look alike as renames, and goes on down
+func (m *mdMatcher) matchChildren(pa, pb int) {
+	ca, cb := m.a.children(pa), m.b.children(pb)
+	for _, i := range ca {
+		for _, j := range cb {
+			if _, taken := m.rpairs[j]; !taken && m.a[i].title == m.b[j].title {
+				m.pair(i, j)
+				break
+			}
+		}
+	}
+	for _, i := range ca {
+		if _, ok := m.pairs[i]; ok {
+			continue
+		}
+		best, bestScore := -1, minSectionSimilarity
+		for _, j := range cb {
+			if _, taken := m.rpairs[j]; taken {
+				continue
+			}
+			score := max(lineSimilarity(m.a[i].title, m.b[j].title), lineSimilarity(m.a[i].content(), m.b[j].content()))
+			if score >= bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best >= 0 {
+			m.pair(i, best)
+		}
+	}
+	for _, i := range ca {
+		if j, ok := m.pairs[i]; ok {
+			m.matchChildren(i, j)
+		}
+	}
+}
+
+// matchMoves pairs sections left unmatched on both sides that share a title:
+// they moved to another parent
+func (m *mdMatcher) matchMoves() {
+	for i := 1; i < len(m.a); i++ {
+		if _, ok := m.pairs[i]; ok {
+			continue
+		}
+		for j := 1; j < len(m.b); j++ {
+			if _, taken := m.rpairs[j]; !taken && m.a[i].title == m.b[j].title {
+				m.pair(i, j)
+				m.moved[i] = true
+				m.matchChildren(i, j)
+				break
+			}
+		}
+	}
+}
+
+// markReordered marks as moved the sections whose order among siblings
+// changed: those outside the longest run of siblings that kept their order
+func (m *mdMatcher) markReordered() {
+	for pa, pb := range m.pairs {
+		// Matched children of pa under pb: right index, then left
+		var kept [][2]int
+		for _, i := range m.a.children(pa) {
+			if j, ok := m.pairs[i]; ok && m.b[j].parent == pb && !m.moved[i] {
+				kept = append(kept, [2]int{j, i})
+			}
+		}
+		inOrder := make(map[int]bool, len(kept))
+		for _, p := range longestIncreasing(kept) {
+			inOrder[p[1]] = true
+		}
+		for _, p := range kept {
+			if !inOrder[p[1]] {
+				m.moved[p[1]] = true
+			}
+		}
+	}
+}
+
+// compareSection reports how matched sections i and j differ: renamed,
+// moved, then their text, list items and tables
+func (m *mdMatcher) compareSection(diff *StructuredDiff, i, j int) {
+	sa, sb := m.a[i], m.b[j]
+	path := m.b.path(j)
+	before := len(diff.Changes)
+	add := func(c Change) {
+		diff.Changes = append(diff.Changes, c)
+	}
+
+	renamed := sa.title != sb.title || sa.level != sb.level
+	if renamed {
+		add(Change{Kind: ChangeModified, Path: path, Old: sa.heading(), New: sb.heading(), LeftLine: sa.line, RightLine: sb.line})
+	}
+	if m.moved[i] {
+		add(Change{Kind: ChangeModified, Path: path, Note: fmt.Sprintf("moved from §%s to §%s", sa.number, sb.number), LeftLine: sa.line, RightLine: sb.line})
+	}
+
+	if k, ok := firstTextDifference(sa.text, sb.text); ok {
+		c := Change{Kind: ChangeModified, Path: path, Note: "text changed", LeftLine: sa.line, RightLine: sb.line}
+		if k < len(sa.text) {
+			c.LeftLine = sa.text[k].line
+		}
+		if k < len(sb.text) {
+			c.RightLine = sb.text[k].line
+		}
+		add(c)
+	}
+
+	for _, c := range compareItems(sa.items, sb.items) {
+		c.Path = path + " > list"
+		add(c)
+	}
+
+	for t := 0; t < len(sa.tables) || t < len(sb.tables); t++ {
+		tpath := path + " > table"
+		if len(sa.tables) > 1 || len(sb.tables) > 1 {
+			tpath += fmt.Sprintf("[%d]", t+1)
+		}
+		switch {
+		case t >= len(sa.tables):
+			add(Change{Kind: ChangeAdded, Path: tpath, New: strings.Join(sb.tables[t].header, " | "), RightLine: sb.line})
+		case t >= len(sb.tables):
+			add(Change{Kind: ChangeRemoved, Path: tpath, Old: strings.Join(sa.tables[t].header, " | "), LeftLine: sa.line})
+		default:
+			for _, c := range compareMDTables(sa.tables[t], sb.tables[t]) {
+				c.Path = tpath + c.Path
+				add(c)
+			}
+		}
+	}
+
+	if len(diff.Changes) > before && i > 0 {
+		sc := SectionChange{Kind: ChangeModified, Path: path, Moved: m.moved[i], LeftLine: sa.line, RightLine: sb.line}
+		if renamed {
+			sc.OldTitle = sa.title
+		}
+		m.outline = append(m.outline, sc)
+	}
+}
+
+// firstTextDifference returns the index of the first line that differs
+func firstTextDifference(a, b []mdLine) (int, bool) {
+	for k := 0; k < len(a) || k < len(b); k++ {
+		if k >= len(a) || k >= len(b) || a[k].text != b[k].text {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// compareItems compares list items as a multiset, so that reordering and
+// renumbering are not changes. An item only on the left that resembles one
+// only on the right is reported as edited; the rest as removed, then added.
+func compareItems(a, b []mdLine) []Change {
+	var removed, added []mdLine
+	inB := itemCounts(b)
+	for _, it := range a {
+		if inB[it.text] > 0 {
+			inB[it.text]--
+		} else {
+			removed = append(removed, it)
+		}
+	}
+	inA := itemCounts(a)
+	for _, it := range b {
+		if inA[it.text] > 0 {
+			inA[it.text]--
+		} else {
+			added = append(added, it)
+		}
+	}
+
+	// Pair the most alike items first
+	type candidate struct {
+		r, a  int
+		score float64
+	}
+	var candidates []candidate
+	for r := range removed {
+		for k := range added {
+			if score := lineSimilarity(removed[r].text, added[k].text); score >= minItemSimilarity {
+				candidates = append(candidates, candidate{r, k, score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(x, y int) bool { return candidates[x].score > candidates[y].score })
+	pairOf := make(map[int]int)
+	paired := make([]bool, len(added))
+	for _, c := range candidates {
+		if _, done := pairOf[c.r]; !done && !paired[c.a] {
+			pairOf[c.r], paired[c.a] = c.a, true
+		}
+	}
+
+	var out []Change
+	for r, it := range removed {
+		k, ok := pairOf[r]
+		if !ok {
+			out = append(out, Change{Kind: ChangeRemoved, Old: valueText(it.text), LeftLine: it.line})
+			continue
+		}
+		out = append(out, Change{Kind: ChangeModified, Old: valueText(it.text), New: valueText(added[k].text), LeftLine: it.line, RightLine: added[k].line})
+	}
+	for k, ad := range added {
+		if !paired[k] {
+			out = append(out, Change{Kind: ChangeAdded, New: valueText(ad.text), RightLine: ad.line})
+		}
+	}
+	return out
+}
+
+func itemCounts(items []mdLine) map[string]int {
+	count := make(map[string]int, len(items))
+	for _, it := range items {
+		count[it.text]++
+	}
+	return count
+}
+
+// compareMDTables compares the rows of two tables keyed by their first
+// cell. Paths of the changes are relative: " header" or "[key]".
+func compareMDTables(a, b mdTable) []Change {
+	var out []Change
+	if strings.Join(a.header, "|") != strings.Join(b.header, "|") {
+		out = append(out, Change{Kind: ChangeModified, Path: " header", Old: strings.Join(a.header, " | "), New: strings.Join(b.header, " | ")})
+	}
+	key := func(r mdRow) string {
+		if len(r.cells) == 0 {
+			return ""
+		}
+		return r.cells[0]
+	}
+	right := make(map[string][]mdRow)
+	for _, r := range b.rows {
+		right[key(r)] = append(right[key(r)], r)
+	}
+	used := make(map[string]int)
+	for _, r := range a.rows {
+		k := key(r)
+		p := fmt.Sprintf("[%s]", valueText(k))
+		used[k]++
+		rows := right[k]
+		if len(rows) < used[k] {
+			out = append(out, Change{Kind: ChangeRemoved, Path: p, Old: strings.Join(r.cells, " | "), LeftLine: r.line})
+			continue
+		}
+		rb := rows[used[k]-1]
+		if strings.Join(r.cells, "|") != strings.Join(rb.cells, "|") {
+			out = append(out, Change{Kind: ChangeModified, Path: p, Old: strings.Join(r.cells, " | "), New: strings.Join(rb.cells, " | "), LeftLine: r.line, RightLine: rb.line})
+		}
+	}
+	// Rows of b beyond the ones matched to a's rows of the same key
+	for _, r := range b.rows {
+		k := key(r)
+		if used[k] > 0 {
+			used[k]--
+			continue
+		}
+		out = append(out, Change{Kind: ChangeAdded, Path: fmt.Sprintf("[%s]", valueText(k)), New: strings.Join(r.cells, " | "), RightLine: r.line})
+	}
+	return out
+}
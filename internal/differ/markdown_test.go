@@ -0,0 +1,101 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareMarkdownOutline(t *testing.T) {
+	left := `# Guide
+
+## Install
+
+Run the installer.
+
+## Usage
+
+Start it.
+
+## Legacy
+
+Old notes.
+
+## FAQ
+
+### Why?
+
+Because.
+`
+	right := `# Guide
+
+## Usage
+
+Start it.
+
+## Installation
+
+Run the installer.
+
+## FAQ
+
+## Why?
+
+Because.
+
+## Changelog
+
+New.
+`
+	diff := CompareMarkdown(left, right)
+	want := []string{
+		"~ Installation: ## Install → ## Installation",
+		"~ Installation: moved from §1.1 to §1.2",
+		"- Legacy: ## Legacy",
+		"~ Why?: ### Why? → ## Why?",
+		"~ Why?: moved from §1.4.1 to §1.4",
+		"+ Changelog: ## Changelog",
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(diff.Detail.(Outline)) != 4 {
+		t.Errorf("expected 4 changed sections, got %+v", diff.Detail)
+	}
+	if c := diff.Changes[0]; c.LeftLine != 3 || c.RightLine != 7 {
+		t.Errorf("Installation lines = %d, %d, want 3, 7", c.LeftLine, c.RightLine)
+	}
+}
+
+func TestCompareMarkdownItems(t *testing.T) {
+	left := "Intro\n=====\n\n1. Copy the config\n2. Start the server\n3. Open the page\n\n| Name | Port |\n|------|-----:|\n| web | 80 |\n| db | 5432 |\n\n```\n# not a heading\n```\n"
+	right := "Intro\n=====\n\n1. Start the server\n2. Copy the config file\n3. Check the logs\n\n| Name | Port |\n| --- | --- |\n| db | 5433 |\n| web | 80 |\n| cache | 6379 |\n\n```\n# not a heading\n```\n"
+
+	diff := CompareMarkdown(left, right)
+	want := []string{
+		`~ Intro > list: "Copy the config" → "Copy the config file"`,
+		`- Intro > list: "Open the page"`,
+		`+ Intro > list: "Check the logs"`,
+		`~ Intro > table["db"]: db | 5432 → db | 5433`,
+		`+ Intro > table["cache"]: cache | 6379`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareMarkdownText(t *testing.T) {
+	left := "# Notes\n\nSome   text\nthat wraps.\n"
+	right := "# Notes\n\nSome text\nthat wraps.\n"
+	if diff := CompareMarkdown(left, right); len(diff.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", changeStrings(diff))
+	}
+
+	diff := CompareMarkdown(left, "# Notes\n\nSome text\nthat wrapped.\n")
+	want := []string{"~ Notes: text changed"}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if c := diff.Changes[0]; c.LeftLine != 4 || c.RightLine != 4 {
+		t.Errorf("text lines = %d, %d, want 4, 4", c.LeftLine, c.RightLine)
+	}
+}
@@ -0,0 +1,316 @@
+package differ
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CompareXML compares two XML documents as canonical trees: attribute
+// order, empty-element forms such as <a/> and <a></a>, indentation and
+// other whitespace between words, comments and processing instructions do
+// not count. Changes are reported by XPath-like location, such as
+// "/config/server[2]/@port" for an attribute or ".../text()" for an
+// element's text, and carry the line of the element in each file. Sibling
+// elements are matched by their id attribute when they have one, otherwise
+// by position among siblings of the same name. Namespaced elements and
+// attributes are named with the prefix the document binds their namespace
+// to, such as "media:title". It fails if either side is not well-formed.
+func CompareXML(left, right string) (*StructuredDiff, error) {
+	return compareMarkup(left, right, false)
+}
+
+// CompareHTML compares two HTML documents the way CompareXML compares XML,
+// but parsed leniently: element and attribute names are case-insensitive,
+// void elements such as <br> need no closing tag and HTML entities are
+// known.
+func CompareHTML(left, right string) (*StructuredDiff, error) {
+	return compareMarkup(left, right, true)
+}
+
+func compareMarkup(left, right string, html bool) (*StructuredDiff, error) {
+	format := "XML"
+	if html {
+		format = "HTML"
+	}
+	a, err := parseMarkup(left, html)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing left %s: %w", format, err)
+	}
+	b, err := parseMarkup(right, html)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing right %s: %w", format, err)
+	}
+	diff := &StructuredDiff{Format: format}
+	compareElementChildren(diff, "", a, b)
+	return diff, nil
+}
+
+// markupNode is an element of a canonicalised document
+type markupNode struct {
+	name     string
+	attrs    map[string]string
+	text     string // the element's own text, whitespace collapsed
+	line     int
+	textLine int
+	children []*markupNode
+}
+
+// parseMarkup parses content into a tree under a nameless root node
+func parseMarkup(content string, html bool) (*markupNode, error) {
+	d := xml.NewDecoder(strings.NewReader(content))
+	if html {
+		d.Strict = false
+		d.AutoClose = xml.HTMLAutoClose
+		d.Entity = xml.HTMLEntity
+	}
+
+	root := &markupNode{}
+	stack := []*markupNode{root}
+	texts := make(map[*markupNode][]string)
+	ns := xmlNamespaces{{prefix: "xml", uri: xmlNamespaceURI}}
+	for {
+		// A token starts where the one before it ended
+		line, _ := d.InputPos()
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			ns = ns.push(t.Attr, len(stack))
+			n := &markupNode{name: ns.name(t.Name, html), attrs: make(map[string]string, len(t.Attr)), line: line}
+			for _, a := range t.Attr {
+				n.attrs[ns.attrName(a.Name, html)] = a.Value
+			}
+			top.children = append(top.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			ns = ns.pop(len(stack))
+		case xml.CharData:
+			raw := string(t)
+			text := strings.Join(strings.Fields(raw), " ")
+			if text == "" || top == root {
+				continue
+			}
+			if len(texts[top]) == 0 {
+				lead := raw[:len(raw)-len(strings.TrimLeft(raw, " \t\r\n"))]
+				top.textLine = line + strings.Count(lead, "\n")
+			}
+			texts[top] = append(texts[top], text)
+		}
+	}
+	for n, parts := range texts {
+		n.text = strings.Join(parts, " ")
+	}
+	return root, nil
+}
+
+// xmlNamespaceURI is the namespace the xml: prefix is always bound to
+const xmlNamespaceURI = "http://www.w3.org/XML/1998/namespace"
+
+// xmlNamespaces are the namespace declarations in scope, innermost last.
+// encoding/xml names elements and attributes by namespace URI; these give
+// them back the prefixes the document uses.
+type xmlNamespaces []xmlBinding
+
+type xmlBinding struct {
+	prefix, uri string
+	depth       int // of the element that declares it
+}
+
+// push adds the declarations among the attributes of an element at depth
+func (ns xmlNamespaces) push(attrs []xml.Attr, depth int) xmlNamespaces {
+	for _, a := range attrs {
+		switch {
+		case a.Name.Space == "xmlns":
+			ns = append(ns, xmlBinding{prefix: a.Name.Local, uri: a.Value, depth: depth})
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			ns = append(ns, xmlBinding{uri: a.Value, depth: depth})
+		}
+	}
+	return ns
+}
+
+// pop drops the declarations of the element at depth that just ended
+func (ns xmlNamespaces) pop(depth int) xmlNamespaces {
+	for len(ns) > 0 && ns[len(ns)-1].depth >= depth {
+		ns = ns[:len(ns)-1]
+	}
+	return ns
+}
+
+// prefix returns the prefix bound to namespace uri: "" for the default
+// namespace, and uri itself when it is an undeclared prefix, which
+// encoding/xml leaves as it is
+func (ns xmlNamespaces) prefix(uri string) string {
+	for i := len(ns) - 1; i >= 0; i-- {
+		if ns[i].uri == uri {
+			return ns[i].prefix
+		}
+	}
+	return uri
+}
+
+// name names an element by its prefixed name, such as "atom:link", or by
+// its local name lower-cased for HTML
+func (ns xmlNamespaces) name(name xml.Name, html bool) string {
+	if html {
+		return strings.ToLower(name.Local)
+	}
+	if p := ns.prefix(name.Space); name.Space != "" && p != "" {
+		return p + ":" + name.Local
+	}
+	return name.Local
+}
+
+// attrName names an attribute as name does an element. Unprefixed
+// attributes are in no namespace, whatever the default namespace is.
+func (ns xmlNamespaces) attrName(name xml.Name, html bool) string {
+	switch {
+	case name.Space == "xmlns":
+		return "xmlns:" + name.Local
+	case name.Space == "" || html:
+		return ns.name(name, html)
+	}
+	return ns.prefix(name.Space) + ":" + name.Local
+}
+
+// startTag renders an element's start tag with sorted attributes, and its
+// text if it has no child elements
+func (n *markupNode) startTag() string {
+	var b strings.Builder
+	b.WriteString("<" + n.name)
+	for _, k := range sortedKeys(n.attrs) {
+		fmt.Fprintf(&b, " %s=%q", k, n.attrs[k])
+	}
+	b.WriteString(">")
+	if n.text != "" && len(n.children) == 0 {
+		b.WriteString(n.text + "</" + n.name + ">")
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareElements compares two matched elements at path: attributes, text,
+// then child elements
+func compareElements(diff *StructuredDiff, path string, a, b *markupNode) {
+	for _, k := range sortedKeys(a.attrs) {
+		p := path + "/@" + k
+		bv, ok := b.attrs[k]
+		switch {
+		case !ok:
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeRemoved, Path: p, Old: valueText(a.attrs[k]), LeftLine: a.line})
+		case a.attrs[k] != bv:
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeModified, Path: p, Old: valueText(a.attrs[k]), New: valueText(bv), LeftLine: a.line, RightLine: b.line})
+		}
+	}
+	for _, k := range sortedKeys(b.attrs) {
+		if _, ok := a.attrs[k]; !ok {
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeAdded, Path: path + "/@" + k, New: valueText(b.attrs[k]), RightLine: b.line})
+		}
+	}
+
+	if a.text != b.text {
+		c := Change{Kind: ChangeModified, Path: path + "/text()", Old: valueText(a.text), New: valueText(b.text), LeftLine: a.textLine, RightLine: b.textLine}
+		switch {
+		case a.text == "":
+			c.Kind, c.Old, c.LeftLine = ChangeAdded, "", 0
+		case b.text == "":
+			c.Kind, c.New, c.RightLine = ChangeRemoved, "", 0
+		}
+		diff.Changes = append(diff.Changes, c)
+	}
+
+	compareElementChildren(diff, path, a, b)
+}
+
+// compareElementChildren matches the child elements of a and b by step,
+// reporting changed and removed ones in left order, then added ones
+func compareElementChildren(diff *StructuredDiff, path string, a, b *markupNode) {
+	aSteps, bSteps := childSteps(a, b), childSteps(b, a)
+	right := make(map[string]int, len(bSteps))
+	for j, s := range bSteps {
+		right[s] = j
+	}
+	seen := make(map[string]bool, len(aSteps))
+	for i, s := range aSteps {
+		seen[s] = true
+		ac := a.children[i]
+		j, ok := right[s]
+		if !ok {
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeRemoved, Path: path + "/" + s, Old: ac.startTag(), LeftLine: ac.line})
+			continue
+		}
+		compareElements(diff, path+"/"+s, ac, b.children[j])
+	}
+	for j, s := range bSteps {
+		if !seen[s] {
+			bc := b.children[j]
+			diff.Changes = append(diff.Changes, Change{Kind: ChangeAdded, Path: path + "/" + s, New: bc.startTag(), RightLine: bc.line})
+		}
+	}
+}
+
+// childSteps names each child element of n as an XPath step: name[@id="x"]
+// for children with a unique id, otherwise the name, followed by [k] for
+// the k-th of its name when n or other has several of that name
+func childSteps(n, other *markupNode) []string {
+	mine, byID := n.positional()
+	theirs, _ := other.positional()
+
+	steps := make([]string, len(n.children))
+	seen := make(map[string]int)
+	for i, c := range n.children {
+		if byID[c] {
+			steps[i] = fmt.Sprintf("%s[@id=%q]", c.name, c.attrs["id"])
+			continue
+		}
+		seen[c.name]++
+		if mine[c.name] > 1 || theirs[c.name] > 1 {
+			steps[i] = fmt.Sprintf("%s[%d]", c.name, seen[c.name])
+		} else {
+			steps[i] = c.name
+		}
+	}
+	return steps
+}
+
+// positional splits the children of n into those identified by an id
+// unique among siblings of their name and the rest, which it counts by name
+func (n *markupNode) positional() (counts map[string]int, byID map[*markupNode]bool) {
+	ids := make(map[string]int)
+	for _, c := range n.children {
+		if id, ok := c.attrs["id"]; ok {
+			ids[c.name+"\x00"+id]++
+		}
+	}
+	counts, byID = make(map[string]int), make(map[*markupNode]bool)
+	for _, c := range n.children {
+		if id, ok := c.attrs["id"]; ok && ids[c.name+"\x00"+id] == 1 {
+			byID[c] = true
+		} else {
+			counts[c.name]++
+		}
+	}
+	return counts, byID
+}
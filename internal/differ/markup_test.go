@@ -0,0 +1,117 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareXMLCanonical(t *testing.T) {
+	left := `<?xml version="1.0"?>
+<!-- settings -->
+<config version="1" env="prod">
+  <server host="a" port="80"></server>
+  <name>
+    demo   app
+  </name>
+</config>
+`
+	right := `<config env="prod" version="1"><server port="80" host="a"/><name>demo app</name></config>`
+
+	diff, err := CompareXML(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", changeStrings(diff))
+	}
+}
+
+func TestCompareXMLLocations(t *testing.T) {
+	left := `<config>
+  <server id="web" port="80"/>
+  <server id="db" port="5432"/>
+  <item>a</item>
+  <item>b</item>
+  <legacy/>
+</config>
+`
+	right := `<config>
+  <server id="db" port="5433"/>
+  <server id="web" port="80" tls="on"/>
+  <item>a</item>
+  <item>c</item>
+  <item>d</item>
+</config>
+`
+	diff, err := CompareXML(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`+ /config/server[@id="web"]/@tls: "on"`,
+		`~ /config/server[@id="db"]/@port: "5432" → "5433"`,
+		`~ /config/item[2]/text(): "b" → "c"`,
+		`- /config/legacy: <legacy>`,
+		`+ /config/item[3]: <item>d</item>`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	lines := map[string][2]int{}
+	for _, c := range diff.Changes {
+		lines[c.Path] = [2]int{c.LeftLine, c.RightLine}
+	}
+	if got := lines[`/config/server[@id="db"]/@port`]; got != [2]int{3, 2} {
+		t.Errorf("port lines = %v, want [3 2]", got)
+	}
+	if got := lines["/config/item[2]/text()"]; got != [2]int{5, 5} {
+		t.Errorf("text lines = %v, want [5 5]", got)
+	}
+}
+
+func TestCompareXMLNamespaces(t *testing.T) {
+	left := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <title>News</title>
+  <media:title>Pictures</media:title>
+  <link xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="a.html" href="b.html"/>
+</feed>
+`
+	right := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <title>News</title>
+  <media:title>Photos</media:title>
+  <link xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="c.html" href="b.html"/>
+</feed>
+`
+	diff, err := CompareXML(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`~ /feed/media:title/text(): "Pictures" → "Photos"`,
+		`~ /feed/link/@xlink:href: "a.html" → "c.html"`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareHTML(t *testing.T) {
+	left := "<!DOCTYPE html>\n<HTML><body>\n<p Class=\"x\">Hello&nbsp;there<br>\n<img src=\"a.png\">\n</body></HTML>\n"
+	right := "<html><body>\n<p class=\"x\">Hello&nbsp;there<br/>\n<img src=\"b.png\"/>\n</p></body></html>\n"
+
+	diff, err := CompareHTML(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`~ /html/body/p/img/@src: "a.png" → "b.png"`}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompareXMLInvalid(t *testing.T) {
+	if _, err := CompareXML("<a/>", "<a><b></a>"); err == nil {
+		t.Error("expected an error for malformed XML")
+	}
+}
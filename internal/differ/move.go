@@ -0,0 +1,146 @@
+package differ
+
+import "strings"
+
+// Move links a block of deleted lines to the block of inserted lines it
+// was moved to. Both ranges are indexes into FileDiff.Lines.
+type Move struct {
+	ID        int  // shared by every line of the move via DiffLine.MoveID
+	FromStart int  // first deleted line
+	FromEnd   int  // just past the last deleted line
+	ToStart   int  // first inserted line
+	ToEnd     int  // just past the last inserted line
+	Exact     bool // false when lines were reindented or slightly edited
+}
+
+const (
+	// minMoveLines is the smallest block reported as a move
+	minMoveLines = 3
+	// minMoveChars keeps blocks of braces and blank lines from counting
+	minMoveChars = 20
+	// nearLineSimilarity is how alike two lines must be to continue a
+	// block when they are not identical
+	nearLineSimilarity = 0.8
+	// maxMoveCandidates bounds the start positions tried per line
+	maxMoveCandidates = 16
+)
+
+// detectMoves finds deleted blocks that reappear as inserted blocks
+// elsewhere and tags them with a shared move ID. Lines are compared with
+// whitespace runs collapsed so reindented code still counts as moved, and a
+// block may continue through a line that was slightly edited.
+func detectMoves(lines []DiffLine) []Move {
+	keys := make([]string, len(lines))
+	inserts := make(map[string][]int)
+	for i, line := range lines {
+		if line.Type == DiffEqual || line.Ignored {
+			continue
+		}
+		keys[i] = strings.Join(strings.Fields(line.Content), " ")
+		if line.Type == DiffInsert && len(keys[i]) >= 3 {
+			inserts[keys[i]] = append(inserts[keys[i]], i)
+		}
+	}
+
+	// equalBefore[i] counts equal lines before index i, to tell a move from
+	// an in-place rewrite
+	equalBefore := make([]int, len(lines)+1)
+	for i, line := range lines {
+		equalBefore[i+1] = equalBefore[i]
+		if line.Type == DiffEqual {
+			equalBefore[i+1]++
+		}
+	}
+
+	isFree := func(i int, t DiffType) bool {
+		return i < len(lines) && lines[i].Type == t && !lines[i].Ignored && lines[i].MoveID == 0
+	}
+
+	var moves []Move
+	for i := 0; i < len(lines); i++ {
+		if !isFree(i, DiffDelete) {
+			continue
+		}
+		cands := inserts[keys[i]]
+		if len(cands) > maxMoveCandidates {
+			cands = cands[:maxMoveCandidates]
+		}
+
+		best := Move{}
+		bestLen := 0
+		for _, j := range cands {
+			// Rule out rewrites in place before paying for the extension
+			if !isFree(j, DiffInsert) || !movedApart(equalBefore, i, i+1, j, j+1) {
+				continue
+			}
+			n := 0
+			for isFree(i+n, DiffDelete) && isFree(j+n, DiffInsert) {
+				if keys[i+n] != keys[j+n] && lineSimilarity(keys[i+n], keys[j+n]) < nearLineSimilarity {
+					break
+				}
+				n++
+			}
+			// Trim trailing near matches so blocks end on identical lines
+			for n > 0 && keys[i+n-1] != keys[j+n-1] {
+				n--
+			}
+			if n <= bestLen || !movedApart(equalBefore, i, i+n, j, j+n) {
+				continue
+			}
+			best = Move{FromStart: i, FromEnd: i + n, ToStart: j, ToEnd: j + n, Exact: true}
+			for k := 0; k < n; k++ {
+				if lines[i+k].Content != lines[j+k].Content {
+					best.Exact = false
+				}
+			}
+			bestLen = n
+		}
+
+		if bestLen < minMoveLines || blockChars(keys[best.FromStart:best.FromEnd]) < minMoveChars {
+			continue
+		}
+		best.ID = len(moves) + 1
+		for k := best.FromStart; k < best.FromEnd; k++ {
+			lines[k].MoveID = best.ID
+		}
+		for k := best.ToStart; k < best.ToEnd; k++ {
+			lines[k].MoveID = best.ID
+		}
+		moves = append(moves, best)
+		i = best.FromEnd - 1
+	}
+	return moves
+}
+
+// movedApart reports whether unchanged lines separate the two blocks. A
+// deletion directly followed by the same text inserted is a rewrite in
+// place, not a move.
+func movedApart(equalBefore []int, fromStart, fromEnd, toStart, toEnd int) bool {
+	if fromEnd <= toStart {
+		return equalBefore[toStart] > equalBefore[fromEnd]
+	}
+	return equalBefore[fromStart] > equalBefore[toEnd]
+}
+
+func blockChars(keys []string) int {
+	n := 0
+	for _, k := range keys {
+		n += len(strings.ReplaceAll(k, " ", ""))
+	}
+	return n
+}
+
+// lineSimilarity returns the Dice coefficient of the two strings' character
+// bigrams: 1 for identical text, 0 for nothing in common.
+func lineSimilarity(a, b string) float64 {
+	return bigramSimilarity(a, b, bigrams(a), bigrams(b))
+}
+
+// MoveAt returns the move a line at index i belongs to, if any
+func (fd *FileDiff) MoveAt(i int) (Move, bool) {
+	id := fd.Lines[i].MoveID
+	if id == 0 || id > len(fd.Moves) {
+		return Move{}, false
+	}
+	return fd.Moves[id-1], true
+}
@@ -0,0 +1,123 @@
+package differ
+
+import "testing"
+
+const movedFunc = "func helper(a, b int) int {\n\tsum := a + b\n\treturn sum * 2\n}\n"
+
+const mainFunc = "func main() {\n\tprintln(1)\n\tprintln(2)\n}\n"
+
+func movedLines(diff *FileDiff, t DiffType) int {
+	n := 0
+	for _, line := range diff.Lines {
+		if line.Type == t && line.MoveID != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDetectMovedFunction(t *testing.T) {
+	left := "package x\n\n" + movedFunc + "\n" + mainFunc
+	right := "package x\n\n" + mainFunc + "\n" + movedFunc
+
+	diff := New().CompareStrings("l", "r", left, right)
+	if len(diff.Moves) != 1 {
+		t.Fatalf("expected 1 move, got %d: %+v", len(diff.Moves), diff.Moves)
+	}
+	mv := diff.Moves[0]
+	if mv.ID != 1 || !mv.Exact {
+		t.Errorf("unexpected move %+v", mv)
+	}
+	if mv.FromEnd-mv.FromStart < minMoveLines || mv.FromEnd-mv.FromStart != mv.ToEnd-mv.ToStart {
+		t.Errorf("move ranges don't line up: %+v", mv)
+	}
+	for i := mv.FromStart; i < mv.FromEnd; i++ {
+		if diff.Lines[i].Type != DiffDelete || diff.Lines[i].MoveID != mv.ID {
+			t.Errorf("line %d should be a deletion in move %d: %+v", i, mv.ID, diff.Lines[i])
+		}
+	}
+	for i := mv.ToStart; i < mv.ToEnd; i++ {
+		if diff.Lines[i].Type != DiffInsert || diff.Lines[i].MoveID != mv.ID {
+			t.Errorf("line %d should be an insertion in move %d: %+v", i, mv.ID, diff.Lines[i])
+		}
+	}
+
+	if got, ok := diff.MoveAt(mv.ToStart); !ok || got.ID != mv.ID {
+		t.Errorf("MoveAt(%d) = %+v, %v", mv.ToStart, got, ok)
+	}
+}
+
+func TestDetectReindentedMove(t *testing.T) {
+	left := movedFunc + "\n" + mainFunc
+	right := mainFunc + "\n" + "  func helper(a, b int) int {\n    sum := a + b\n    return sum * 2\n  }\n"
+
+	diff := New().CompareStrings("l", "r", left, right)
+	if len(diff.Moves) != 1 || diff.Moves[0].Exact {
+		t.Errorf("expected one inexact move, got %+v", diff.Moves)
+	}
+}
+
+func TestNearIdenticalMove(t *testing.T) {
+	left := "func helper(a, b int) int {\n\tsum := a + b\n\treturn sum * 2\n\t// done\n}\n" + "\n" + mainFunc
+	right := mainFunc + "\n" + "func helper(a, b int) int {\n\tsum := a + b\n\treturn sum * 3\n\t// done\n}\n"
+
+	diff := New().CompareStrings("l", "r", left, right)
+	if len(diff.Moves) != 1 {
+		t.Fatalf("a slightly edited block should still count as moved, got %+v", diff.Moves)
+	}
+	if mv := diff.Moves[0]; mv.FromEnd-mv.FromStart < 4 || mv.Exact {
+		t.Errorf("the edited line should be inside the move: %+v", mv)
+	}
+}
+
+func TestRewriteInPlaceIsNotAMove(t *testing.T) {
+	left := "a\n" + movedFunc + "z\n"
+	right := "a\n" + "func helper(a, b int) int {\n  sum := a + b\n  return sum * 2\n}\n" + "z\n"
+
+	diff := New().CompareStrings("l", "r", left, right)
+	if len(diff.Moves) != 0 {
+		t.Errorf("reindenting in place is not a move: %+v", diff.Moves)
+	}
+}
+
+func TestSmallBlocksAreNotMoves(t *testing.T) {
+	left := "}\n}\n}\nx\ny\nz\n"
+	right := "x\ny\nz\n}\n}\n}\n"
+
+	diff := New().CompareStrings("l", "r", left, right)
+	if movedLines(diff, DiffDelete) != 0 {
+		t.Errorf("blocks of braces should not be reported as moves: %+v", diff.Moves)
+	}
+}
+
+func TestMovedLinesAreNotPaired(t *testing.T) {
+	left := movedFunc + "\n" + mainFunc
+	right := mainFunc + "\n" + movedFunc
+
+	for _, row := range BuildSideBySideRows(New().CompareStrings("l", "r", left, right).Lines) {
+		if row.Type == SBSModified && row.MoveID != 0 {
+			t.Errorf("moved line shown as a modified pair: %+v", row)
+		}
+	}
+}
+
+func TestDetectMovesOption(t *testing.T) {
+	left := movedFunc + "\n" + mainFunc
+	right := mainFunc + "\n" + movedFunc
+	diff := NewWithOptions(Options{}).CompareStrings("l", "r", left, right)
+	if len(diff.Moves) != 0 || movedLines(diff, DiffInsert) != 0 {
+		t.Errorf("moves should not be detected when DetectMoves is off")
+	}
+}
+
+func TestLineSimilarity(t *testing.T) {
+	if got := lineSimilarity("return sum * 2", "return sum * 2"); got != 1 {
+		t.Errorf("identical similarity = %v, want 1", got)
+	}
+	if got := lineSimilarity("return sum * 2", "return sum * 3"); got < nearLineSimilarity {
+		t.Errorf("one-character edit similarity = %v, want >= %v", got, nearLineSimilarity)
+	}
+	if got := lineSimilarity("abc", "xyz"); got != 0 {
+		t.Errorf("disjoint similarity = %v, want 0", got)
+	}
+}
@@ -0,0 +1,228 @@
+package differ
+
+// internLines maps every distinct line to a small integer so the diff
+// algorithms compare ints instead of strings. Lines are compared by their
+// key, which lets callers decide what "equal" means.
+func internLines(left, right []string, key func(string) string) ([]int, []int) {
+	ids := make(map[string]int, len(left)+len(right))
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			k := key(line)
+			id, ok := ids[k]
+			if !ok {
+				id = len(ids)
+				ids[k] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	return intern(left), intern(right)
+}
+
+// myersMatches returns the matched (leftIndex, rightIndex) pairs of a
+// minimal edit script between a and b, in increasing order.
+//
+// Lines that occur on only one side can never be part of a match, so they
+// are dropped before running Myers and the indexes are mapped back
+// afterwards. This keeps completely rewritten regions cheap.
+func myersMatches(a, b []int) [][2]int {
+	return budgetedMyers(a, b, nil)
+}
+
+// budgetedMyers is myersMatches with a limit on the work done; see budget
+func budgetedMyers(a, b []int, bud *budget) [][2]int {
+	inA := make(map[int]bool, len(a))
+	for _, x := range a {
+		inA[x] = true
+	}
+	inB := make(map[int]bool, len(b))
+	for _, x := range b {
+		inB[x] = true
+	}
+
+	var fa, fb, mapA, mapB []int
+	for i, x := range a {
+		if inB[x] {
+			fa = append(fa, x)
+			mapA = append(mapA, i)
+		}
+	}
+	for j, x := range b {
+		if inA[x] {
+			fb = append(fb, x)
+			mapB = append(mapB, j)
+		}
+	}
+
+	s := newMyers(fa, fb, bud)
+	s.compare(0, len(fa), 0, len(fb))
+
+	for i := range s.matches {
+		s.matches[i] = [2]int{mapA[s.matches[i][0]], mapB[s.matches[i][1]]}
+	}
+	return s.matches
+}
+
+// myers holds the state of one linear-space Myers diff run. The V arrays
+// are allocated once and reused by every recursive call.
+type myers struct {
+	a, b    []int
+	vf, vb  []int
+	matches [][2]int
+	budget  *budget
+}
+
+func newMyers(a, b []int, bud *budget) *myers {
+	size := len(a) + len(b) + 5
+	return &myers{
+		a:      a,
+		b:      b,
+		vf:     make([]int, size),
+		vb:     make([]int, size),
+		budget: bud,
+	}
+}
+
+// compare diffs a[aLo:aHi] against b[bLo:bHi], appending matches in order.
+func (s *myers) compare(aLo, aHi, bLo, bHi int) {
+	// Common prefix
+	for aLo < aHi && bLo < bHi && s.a[aLo] == s.b[bLo] {
+		s.matches = append(s.matches, [2]int{aLo, bLo})
+		aLo++
+		bLo++
+	}
+
+	// Common suffix (emitted after the middle is done)
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && s.a[aHi-suffix-1] == s.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	if aLo < aHi && bLo < bHi {
+		if x, y, u, v, ok := s.middleSnake(aLo, aHi, bLo, bHi); ok {
+			s.compare(aLo, x, bLo, y)
+			for x < u {
+				s.matches = append(s.matches, [2]int{x, y})
+				x++
+				y++
+			}
+			s.compare(u, aHi, v, bHi)
+		} else {
+			s.approximate(aLo, aHi, bLo, bHi)
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		s.matches = append(s.matches, [2]int{aHi + i, bHi + i})
+	}
+}
+
+// approximate aligns a[aLo:aHi] and b[bLo:bHi] on lines unique to both,
+// as patience diff does, once the budget no longer allows an exact search.
+// The gaps between anchors are handed back to compare, which trims their
+// common ends and comes straight back here.
+func (s *myers) approximate(aLo, aHi, bLo, bHi int) {
+	if s.budget.cancelled() {
+		return
+	}
+	anchors := uniqueAnchors(s.a, s.b, aLo, aHi, bLo, bHi)
+	if len(anchors) == 0 {
+		return // no alignment to be had cheaply: the whole range changed
+	}
+	for _, anchor := range anchors {
+		s.compare(aLo, anchor[0], bLo, anchor[1])
+		s.matches = append(s.matches, anchor)
+		aLo, bLo = anchor[0]+1, anchor[1]+1
+	}
+	s.compare(aLo, aHi, bLo, bHi)
+}
+
+// middleSnake finds the middle snake of the optimal path through the edit
+// graph of a[aLo:aHi] and b[bLo:bHi]. It returns the snake's start (x, y)
+// and end (u, v) in absolute indexes, or ok == false if the budget ran out
+// before the searches met.
+func (s *myers) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+
+	// Diagonals are clamped so every visited point stays inside the grid.
+	// The same bounds apply to the backward search, whose grid is the same
+	// size, which lets the overlap checks skip entries not yet written.
+	kLo := func(d int) int { return -d + 2*maxInt(0, d-m) }
+	kHi := func(d int) int { return d - 2*maxInt(0, d-n) }
+
+	vf, vb := s.vf, s.vb
+	vf[off+1] = 0
+	vb[off+1] = 0
+
+	for d := 0; d <= maxD; d++ {
+		if !s.budget.spend(kHi(d) - kLo(d) + 1) {
+			return 0, 0, 0, 0, false
+		}
+
+		// Forward search from the top-left corner
+		for k := kLo(d); k <= kHi(d); k += 2 {
+			var px int
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				px = vf[off+k+1]
+			} else {
+				px = vf[off+k-1] + 1
+			}
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && s.a[aLo+px] == s.b[bLo+py] {
+				px++
+				py++
+			}
+			vf[off+k] = px
+
+			if odd && d > 0 {
+				rk := delta - k
+				if rk >= kLo(d-1) && rk <= kHi(d-1) && px+vb[off+rk] >= n {
+					return aLo + sx, bLo + sy, aLo + px, bLo + py, true
+				}
+			}
+		}
+
+		// Backward search from the bottom-right corner, in reversed coordinates
+		for k := kLo(d); k <= kHi(d); k += 2 {
+			var px int
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				px = vb[off+k+1]
+			} else {
+				px = vb[off+k-1] + 1
+			}
+			py := px - k
+			sx, sy := px, py
+			for px < n && py < m && s.a[aHi-1-px] == s.b[bHi-1-py] {
+				px++
+				py++
+			}
+			vb[off+k] = px
+
+			if !odd {
+				fk := delta - k
+				if fk >= kLo(d) && fk <= kHi(d) && vf[off+fk]+px >= n {
+					return aHi - px, bHi - py, aHi - sx, bHi - sy, true
+				}
+			}
+		}
+	}
+
+	// Unreachable for well-formed input: the searches always meet by maxD.
+	return aLo, bLo, aLo, bLo, true
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
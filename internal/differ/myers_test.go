@@ -0,0 +1,115 @@
+package differ
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// ---- helpers ----------------------------------------------------------------
+
+// lcsLength is the textbook quadratic LCS, used as a reference for small inputs.
+func lcsLength(a, b []int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				cur[j] = prev[j-1] + 1
+			} else if prev[j] >= cur[j-1] {
+				cur[j] = prev[j]
+			} else {
+				cur[j] = cur[j-1]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func randomSeq(r *rand.Rand, n, alphabet int) []int {
+	seq := make([]int, n)
+	for i := range seq {
+		seq[i] = r.Intn(alphabet)
+	}
+	return seq
+}
+
+func checkMatches(t *testing.T, a, b []int, matches [][2]int) {
+	t.Helper()
+	prevA, prevB := -1, -1
+	for _, mt := range matches {
+		if mt[0] <= prevA || mt[1] <= prevB {
+			t.Fatalf("matches not strictly increasing: %v", matches)
+		}
+		if a[mt[0]] != b[mt[1]] {
+			t.Fatalf("match %v pairs unequal elements %d != %d", mt, a[mt[0]], b[mt[1]])
+		}
+		prevA, prevB = mt[0], mt[1]
+	}
+}
+
+// ---- myersMatches -----------------------------------------------------------
+
+func TestMyersMatchesIsMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 500; iter++ {
+		a := randomSeq(r, r.Intn(30), 1+r.Intn(6))
+		b := randomSeq(r, r.Intn(30), 1+r.Intn(6))
+		matches := myersMatches(a, b)
+		checkMatches(t, a, b, matches)
+		if want := lcsLength(a, b); len(matches) != want {
+			t.Fatalf("a=%v b=%v: got %d matches, want LCS %d", a, b, len(matches), want)
+		}
+	}
+}
+
+func TestMyersMatchesEmpty(t *testing.T) {
+	if got := myersMatches(nil, []int{1, 2}); len(got) != 0 {
+		t.Errorf("expected no matches against empty input, got %v", got)
+	}
+	if got := myersMatches([]int{1, 2}, nil); len(got) != 0 {
+		t.Errorf("expected no matches against empty input, got %v", got)
+	}
+}
+
+// ---- large inputs -----------------------------------------------------------
+
+func TestCompareLargeGeneratedFiles(t *testing.T) {
+	// 60k lines with a handful of edits must not allocate a quadratic table.
+	const n = 60000
+	var left, right strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&left, "line %d\n", i)
+		if i%10000 == 5000 {
+			fmt.Fprintf(&right, "changed %d\n", i)
+			continue
+		}
+		fmt.Fprintf(&right, "line %d\n", i)
+	}
+
+	diff := New().CompareStrings("l", "r", left.String(), right.String())
+	eq, ins, del := diff.GetStats()
+	if ins != 6 || del != 6 {
+		t.Errorf("expected 6 insertions and 6 deletions, got ins=%d del=%d", ins, del)
+	}
+	if eq != n-6 {
+		t.Errorf("expected %d equal lines, got %d", n-6, eq)
+	}
+}
+
+func TestCompareCompletelyDifferentLargeFiles(t *testing.T) {
+	const n = 60000
+	var left, right strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&left, "left %d\n", i)
+		fmt.Fprintf(&right, "right %d\n", i)
+	}
+
+	diff := New().CompareStrings("l", "r", left.String(), right.String())
+	eq, ins, del := diff.GetStats()
+	if eq != 0 || ins != n || del != n {
+		t.Errorf("expected 0/%d/%d, got eq=%d ins=%d del=%d", n, n, eq, ins, del)
+	}
+}
@@ -0,0 +1,243 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WhitespaceMode controls how whitespace differences affect line matching
+type WhitespaceMode int
+
+const (
+	WhitespaceExact          WhitespaceMode = iota // whitespace is significant
+	WhitespaceIgnoreTrailing                       // ignore whitespace at end of line
+	WhitespaceIgnoreAmount                         // treat any run of whitespace as one space
+	WhitespaceIgnoreAll                            // ignore all whitespace
+)
+
+// WhitespaceModes lists every mode in cycling order
+var WhitespaceModes = []WhitespaceMode{
+	WhitespaceExact, WhitespaceIgnoreTrailing, WhitespaceIgnoreAmount, WhitespaceIgnoreAll,
+}
+
+// String returns the short name shown in the UI
+func (w WhitespaceMode) String() string {
+	switch w {
+	case WhitespaceIgnoreTrailing:
+		return "ignore-trailing"
+	case WhitespaceIgnoreAmount:
+		return "ignore-amount"
+	case WhitespaceIgnoreAll:
+		return "ignore-all"
+	default:
+		return "exact"
+	}
+}
+
+// Next returns the mode that follows w in cycling order
+func (w WhitespaceMode) Next() WhitespaceMode {
+	for i, mode := range WhitespaceModes {
+		if mode == w {
+			return WhitespaceModes[(i+1)%len(WhitespaceModes)]
+		}
+	}
+	return WhitespaceExact
+}
+
+// normalize applies the whitespace mode to a line
+func (w WhitespaceMode) normalize(s string) string {
+	switch w {
+	case WhitespaceIgnoreTrailing:
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	case WhitespaceIgnoreAmount:
+		return strings.Join(strings.Fields(s), " ")
+	case WhitespaceIgnoreAll:
+		return strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, s)
+	default:
+		return s
+	}
+}
+
+// Normalization selects the Unicode normal form lines are compared in, so
+// that text composed differently, such as "é" as one code point or as "e"
+// and a combining accent, compares equal
+type Normalization int
+
+const (
+	NormalizationNone Normalization = iota // code points compare as they are
+	NormalizationNFC                       // canonical composition
+	NormalizationNFKC                      // compatibility composition: also "ﬁ" = "fi", "²" = "2"
+)
+
+// Normalizations lists every normalization in cycling order
+var Normalizations = []Normalization{NormalizationNone, NormalizationNFC, NormalizationNFKC}
+
+// String returns the name used on the command line and in the UI
+func (n Normalization) String() string {
+	switch n {
+	case NormalizationNFC:
+		return "nfc"
+	case NormalizationNFKC:
+		return "nfkc"
+	default:
+		return "none"
+	}
+}
+
+// Next returns the normalization that follows n in cycling order
+func (n Normalization) Next() Normalization {
+	for i, nf := range Normalizations {
+		if nf == n {
+			return Normalizations[(i+1)%len(Normalizations)]
+		}
+	}
+	return NormalizationNone
+}
+
+// ParseNormalization converts a name such as "nfc" into a Normalization
+func ParseNormalization(name string) (Normalization, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "none", "":
+		return NormalizationNone, nil
+	case "nfc":
+		return NormalizationNFC, nil
+	case "nfkc":
+		return NormalizationNFKC, nil
+	}
+	return NormalizationNone, fmt.Errorf("unknown Unicode normalization %q (want none, nfc or nfkc)", name)
+}
+
+// normalize applies the normal form to a line
+func (n Normalization) normalize(s string) string {
+	switch n {
+	case NormalizationNFC:
+		return norm.NFC.String(s)
+	case NormalizationNFKC:
+		return norm.NFKC.String(s)
+	default:
+		return s
+	}
+}
+
+// Equalization records which relaxed comparisons made two lines with
+// different text match. It is a bit set so several can apply at once.
+type Equalization uint8
+
+const (
+	EqualizedWhitespace Equalization = 1 << iota
+	EqualizedRule
+	EqualizedEOL
+	EqualizedCase
+	EqualizedUnicode
+)
+
+// equalizationNames lists every flag with its display name, in bit order
+var equalizationNames = []struct {
+	flag Equalization
+	name string
+}{
+	{EqualizedWhitespace, "whitespace"},
+	{EqualizedRule, "rule"},
+	{EqualizedEOL, "eol"},
+	{EqualizedCase, "case"},
+	{EqualizedUnicode, "unicode"},
+}
+
+// Flags returns the individual equalizations set in e, in bit order
+func (e Equalization) Flags() []Equalization {
+	var flags []Equalization
+	for _, en := range equalizationNames {
+		if e&en.flag != 0 {
+			flags = append(flags, en.flag)
+		}
+	}
+	return flags
+}
+
+// String returns a comma-separated list of the applied equalizations
+func (e Equalization) String() string {
+	var names []string
+	for _, en := range equalizationNames {
+		if e&en.flag != 0 {
+			names = append(names, en.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// normalizer is one step of the comparison pipeline. Lines are compared by
+// the result of applying every step in order.
+type normalizer struct {
+	reason Equalization
+	apply  func(string) string
+}
+
+// normalizers returns the comparison pipeline for the differ's options
+func (d *Differ) normalizers() []normalizer {
+	var steps []normalizer
+	// Rules see the original text so patterns can rely on exact spacing
+	for i, rule := range d.IgnoreRules {
+		steps = append(steps, normalizer{reason: EqualizedRule, apply: rule.normalize(i)})
+	}
+	// Composition comes before case folding, which needs the letters whole
+	if d.Normalization != NormalizationNone {
+		steps = append(steps, normalizer{reason: EqualizedUnicode, apply: d.Normalization.normalize})
+	}
+	if d.FoldCase {
+		// A Caser keeps state, so each pipeline gets its own
+		fold := cases.Fold()
+		steps = append(steps, normalizer{reason: EqualizedCase, apply: fold.String})
+	}
+	if d.Whitespace != WhitespaceExact {
+		steps = append(steps, normalizer{reason: EqualizedWhitespace, apply: d.Whitespace.normalize})
+	}
+	return steps
+}
+
+// lineKey returns the function used to compare lines under steps
+func lineKey(steps []normalizer) func(string) string {
+	if len(steps) == 0 {
+		return identity
+	}
+	return func(s string) string {
+		for _, step := range steps {
+			s = step.apply(s)
+		}
+		return s
+	}
+}
+
+// equalizationReason works out which steps were needed to make left and
+// right compare equal. A step is credited when it changed either side
+// while the two were still different.
+func equalizationReason(steps []normalizer, left, right string) Equalization {
+	var reason Equalization
+	for _, step := range steps {
+		if left == right {
+			break
+		}
+		nl, nr := step.apply(left), step.apply(right)
+		if nl != left || nr != right {
+			reason |= step.reason
+		}
+		left, right = nl, nr
+	}
+	return reason
+}
+
+// isBlank reports whether a line contains only whitespace
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
@@ -0,0 +1,165 @@
+package differ
+
+import "testing"
+
+func hasChanges(diff *FileDiff) bool {
+	_, inserted, deleted := diff.GetStats()
+	return inserted+deleted > 0
+}
+
+// ---- WhitespaceMode ---------------------------------------------------------
+
+func TestWhitespaceNormalize(t *testing.T) {
+	in := "\tfoo   bar  \t"
+	cases := map[WhitespaceMode]string{
+		WhitespaceExact:          in,
+		WhitespaceIgnoreTrailing: "\tfoo   bar",
+		WhitespaceIgnoreAmount:   "foo bar",
+		WhitespaceIgnoreAll:      "foobar",
+	}
+	for mode, want := range cases {
+		if got := mode.normalize(in); got != want {
+			t.Errorf("%v: normalize(%q) = %q, want %q", mode, in, got, want)
+		}
+	}
+}
+
+func TestWhitespaceModeNextCycles(t *testing.T) {
+	mode := WhitespaceExact
+	for range WhitespaceModes {
+		mode = mode.Next()
+	}
+	if mode != WhitespaceExact {
+		t.Errorf("cycling through all modes should return to exact, got %v", mode)
+	}
+}
+
+// ---- relaxed comparison -----------------------------------------------------
+
+func TestReindentedLinesAreEqual(t *testing.T) {
+	left := "func f() {\n\treturn 1\n}\n"
+	right := "func f() {\n    return 1\n}\n"
+
+	diff := NewWithOptions(Options{Whitespace: WhitespaceIgnoreAmount}).CompareStrings("l", "r", left, right)
+	if hasChanges(diff) {
+		t.Fatalf("reindented file should have no changes: %+v", diff.Lines)
+	}
+
+	line := diff.Lines[1]
+	if line.Content != "\treturn 1" || line.RightText() != "    return 1" {
+		t.Errorf("equal line should keep both originals, got %q / %q", line.Content, line.RightText())
+	}
+	if line.Equalized != EqualizedWhitespace {
+		t.Errorf("expected whitespace equalization, got %v", line.Equalized)
+	}
+	if line.LeftLineNum != 2 || line.RightLineNum != 2 {
+		t.Errorf("line numbers = %d/%d, want 2/2", line.LeftLineNum, line.RightLineNum)
+	}
+	if diff.Lines[0].Equalized != 0 {
+		t.Errorf("identical line should not be marked equalized")
+	}
+}
+
+func TestExactModeSeesWhitespace(t *testing.T) {
+	diff := New().CompareStrings("l", "r", "a \n", "a\n")
+	if !hasChanges(diff) {
+		t.Error("exact mode should report trailing whitespace as a change")
+	}
+	diff = NewWithOptions(Options{Whitespace: WhitespaceIgnoreTrailing}).CompareStrings("l", "r", "a \n", "a\n")
+	if hasChanges(diff) {
+		t.Error("ignore-trailing should hide trailing whitespace")
+	}
+}
+
+func TestIgnoreBlankLines(t *testing.T) {
+	left := "a\nb\n"
+	right := "a\n\n\nb\n"
+
+	diff := NewWithOptions(Options{IgnoreBlankLines: true}).CompareStrings("l", "r", left, right)
+	if hasChanges(diff) {
+		t.Errorf("added blank lines should be ignored: %+v", diff.Lines)
+	}
+	for _, line := range diff.Lines {
+		if line.Type == DiffInsert && !line.Ignored {
+			t.Errorf("blank insertion should be marked ignored: %+v", line)
+		}
+	}
+
+	if _, inserted, _ := diff.GetStats(); inserted != 0 {
+		t.Errorf("ignored lines should not count as insertions, got %d", inserted)
+	}
+
+	diff = New().CompareStrings("l", "r", left, right)
+	if !hasChanges(diff) {
+		t.Error("blank lines should count by default")
+	}
+}
+
+// ---- case folding and Unicode normalization ---------------------------------
+
+func TestFoldCase(t *testing.T) {
+	left := "SELECT id FROM users;\nHost: Example.COM\n"
+	right := "select id from users;\nhost: example.com\n"
+
+	if !hasChanges(New().CompareStrings("l", "r", left, right)) {
+		t.Fatal("case should matter by default")
+	}
+	diff := NewWithOptions(Options{FoldCase: true}).CompareStrings("l", "r", left, right)
+	if hasChanges(diff) {
+		t.Fatalf("folded case should leave no changes: %+v", diff.Lines)
+	}
+	line := diff.Lines[0]
+	if line.Equalized != EqualizedCase || line.Content != "SELECT id FROM users;" || line.RightText() != "select id from users;" {
+		t.Errorf("equal line should keep both originals and record case folding, got %q / %q (%v)",
+			line.Content, line.RightText(), line.Equalized)
+	}
+	if hasChanges(NewWithOptions(Options{FoldCase: true}).CompareStrings("l", "r", "Straße\n", "STRASSE\n")) {
+		t.Error("full case folding should match ß with SS")
+	}
+}
+
+func TestUnicodeNormalization(t *testing.T) {
+	nfd := "cafe\u0301\n" // e followed by a combining acute accent
+	nfc := "caf\u00e9\n"
+	if !hasChanges(New().CompareStrings("l", "r", nfd, nfc)) {
+		t.Fatal("differently composed text should differ by default")
+	}
+	diff := NewWithOptions(Options{Normalization: NormalizationNFC}).CompareStrings("l", "r", nfd, nfc)
+	if hasChanges(diff) || diff.Lines[0].Equalized != EqualizedUnicode {
+		t.Errorf("NFC should equalize composition, got %+v", diff.Lines)
+	}
+
+	ligature, letters := "e\ufb01le\n", "efile\n"
+	if !hasChanges(NewWithOptions(Options{Normalization: NormalizationNFC}).CompareStrings("l", "r", ligature, letters)) {
+		t.Error("NFC should keep a ligature apart from its letters")
+	}
+	if hasChanges(NewWithOptions(Options{Normalization: NormalizationNFKC}).CompareStrings("l", "r", ligature, letters)) {
+		t.Error("NFKC should match a ligature with its letters")
+	}
+}
+
+func TestCombinedEqualizations(t *testing.T) {
+	opts := Options{FoldCase: true, Normalization: NormalizationNFC, Whitespace: WhitespaceIgnoreAmount}
+	diff := NewWithOptions(opts).CompareStrings("l", "r", "CAF\u00c9  au lait\n", "cafe\u0301 au lait\n")
+	if hasChanges(diff) {
+		t.Fatalf("expected no changes: %+v", diff.Lines)
+	}
+	want := EqualizedCase | EqualizedUnicode | EqualizedWhitespace
+	if got := diff.Lines[0].Equalized; got != want {
+		t.Errorf("Equalized = %v, want %v", got, want)
+	}
+	if got := want.String(); got != "whitespace,case,unicode" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParseNormalization(t *testing.T) {
+	for _, n := range Normalizations {
+		if got, err := ParseNormalization(n.String()); err != nil || got != n {
+			t.Errorf("ParseNormalization(%q) = %v, %v", n, got, err)
+		}
+	}
+	if _, err := ParseNormalization("nfd"); err == nil {
+		t.Error("expected an error for an unsupported form")
+	}
+}
@@ -0,0 +1,63 @@
+package differ
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// DefaultContext is the number of context lines used by diff and git
+const DefaultContext = 3
+
+// noNewlineMarker follows a line that has no terminating newline
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+// WriteUnified writes fd as a unified diff that git apply and patch accept.
+// The ---/+++ headers use fd.LeftFile and fd.RightFile as given. Nothing is
+// written when the files have no changes.
+func WriteUnified(w io.Writer, fd *FileDiff, context int) error {
+	hunks := fd.Hunks(context)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n", fd.LeftFile)
+	fmt.Fprintf(bw, "+++ %s\n", fd.RightFile)
+
+	for _, h := range hunks {
+		fmt.Fprintf(bw, "@@ -%s +%s @@\n", hunkRange(h.LeftStart, h.LeftCount), hunkRange(h.RightStart, h.RightCount))
+		for _, line := range h.Lines {
+			switch line.Type {
+			case DiffEqual:
+				writePatchLine(bw, ' ', line.Content, line.EOL)
+			case DiffDelete:
+				writePatchLine(bw, '-', line.Content, line.EOL)
+			case DiffInsert:
+				writePatchLine(bw, '+', line.Content, line.EOL)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// hunkRange formats a hunk header range, omitting a count of one as diff does
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// writePatchLine writes one hunk line with its original terminator, so
+// CRLF files produce patches that apply byte for byte
+func writePatchLine(w *bufio.Writer, sign byte, content string, eol LineEnding) {
+	w.WriteByte(sign)
+	w.WriteString(content)
+	if eol == EOLNone {
+		w.WriteString("\n" + noNewlineMarker)
+		return
+	}
+	w.WriteString(eol.Terminator())
+}
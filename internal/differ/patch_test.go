@@ -0,0 +1,157 @@
+package differ
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func unified(t *testing.T, fd *FileDiff, context int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteUnified(&buf, fd, context); err != nil {
+		t.Fatalf("WriteUnified: %v", err)
+	}
+	return buf.String()
+}
+
+// applyPatch is a minimal patch(1) for single-file unified diffs with exact
+// line numbers, enough to check that output round-trips.
+func applyPatch(t *testing.T, original, patch string) string {
+	t.Helper()
+	src := strings.SplitAfter(original, "\n")
+	if src[len(src)-1] == "" {
+		src = src[:len(src)-1]
+	}
+
+	var out []string
+	next := 0 // index of the next unconsumed source line
+	lines := strings.SplitAfter(patch, "\n")
+	for i := 2; i < len(lines) && lines[i] != ""; i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			var leftStart int
+			fmt.Sscanf(strings.TrimPrefix(line, "@@ -"), "%d", &leftStart)
+			// A hunk with no left lines inserts after leftStart
+			if strings.HasPrefix(line, "@@ -"+strconv.Itoa(leftStart)+",0 ") {
+				leftStart++
+			}
+			for next < leftStart-1 {
+				out = append(out, src[next])
+				next++
+			}
+		case strings.HasPrefix(line, " "), strings.HasPrefix(line, "-"):
+			if src[next] != line[1:] && src[next] != strings.TrimSuffix(line[1:], "\n") {
+				t.Fatalf("patch context mismatch at source line %d: %q vs %q", next+1, src[next], line)
+			}
+			if line[0] == ' ' {
+				out = append(out, src[next])
+			}
+			next++
+		case strings.HasPrefix(line, "+"):
+			out = append(out, line[1:])
+		case strings.HasPrefix(line, "\\"):
+			// The previous line has no newline
+			prev := lines[i-1]
+			if prev[0] != '-' {
+				out[len(out)-1] = strings.TrimSuffix(out[len(out)-1], "\n")
+			}
+		default:
+			t.Fatalf("unexpected patch line %q", line)
+		}
+	}
+	out = append(out, src[next:]...)
+	return strings.Join(out, "")
+}
+
+// ---- format -----------------------------------------------------------------
+
+func TestWriteUnifiedFormat(t *testing.T) {
+	left := "a\nb\nc\nd\ne\n"
+	right := "a\nb\nC\nd\ne\nf\n"
+	got := unified(t, New().CompareStrings("old.txt", "new.txt", left, right), 1)
+
+	want := "--- old.txt\n+++ new.txt\n" +
+		"@@ -2,4 +2,5 @@\n" +
+		" b\n-c\n+C\n d\n e\n+f\n"
+	if got != want {
+		t.Errorf("unified output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteUnifiedNoChanges(t *testing.T) {
+	if got := unified(t, New().CompareStrings("l", "r", "a\n", "a\n"), 3); got != "" {
+		t.Errorf("identical files should produce no output, got %q", got)
+	}
+}
+
+func TestWriteUnifiedPureInsertAtStart(t *testing.T) {
+	got := unified(t, New().CompareStrings("l", "r", "a\n", "new\na\n"), 0)
+	if !strings.Contains(got, "@@ -0,0 +1 @@\n+new\n") {
+		t.Errorf("unexpected hunk for insertion at start:\n%s", got)
+	}
+}
+
+func TestWriteUnifiedNoNewlineMarker(t *testing.T) {
+	got := unified(t, New().CompareStrings("l", "r", "a\nb\n", "a\nb"), 3)
+	want := "--- l\n+++ r\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("missing-newline output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = unified(t, New().CompareStrings("l", "r", "a\nb", "a\nc"), 3)
+	want = "--- l\n+++ r\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("both-sides missing newline mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// ---- round trip -------------------------------------------------------------
+
+func TestWriteUnifiedRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	words := []string{"alpha", "beta", "gamma", "delta", ""}
+	gen := func() string {
+		var b strings.Builder
+		n := r.Intn(25)
+		for i := 0; i < n; i++ {
+			b.WriteString(words[r.Intn(len(words))])
+			if i < n-1 || r.Intn(4) > 0 {
+				if r.Intn(5) == 0 {
+					b.WriteString("\r\n")
+				} else {
+					b.WriteString("\n")
+				}
+			}
+		}
+		return b.String()
+	}
+
+	for iter := 0; iter < 500; iter++ {
+		left, right := gen(), gen()
+		for _, context := range []int{0, 1, 3} {
+			patch := unified(t, New().CompareStrings("l", "r", left, right), context)
+			if got := applyPatch(t, left, patch); got != right {
+				t.Fatalf("round trip failed (context %d)\nleft: %q\nright: %q\npatch:\n%s\ngot: %q",
+					context, left, right, patch, got)
+			}
+		}
+	}
+}
+
+func TestCompareFilesTracksFinalNewline(t *testing.T) {
+	diff, err := New().CompareFiles("l", "r", strings.NewReader("a\nb"), strings.NewReader("a\nb\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.LeftNoFinalNewline || diff.RightNoFinalNewline {
+		t.Errorf("final newline flags = %v/%v, want true/false", diff.LeftNoFinalNewline, diff.RightNoFinalNewline)
+	}
+	if !hasChanges(diff) {
+		t.Error("a missing final newline should count as a change")
+	}
+}
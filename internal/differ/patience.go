@@ -0,0 +1,99 @@
+package differ
+
+import "sort"
+
+// patienceMatches implements patience diff: lines that occur exactly once on
+// each side are used as anchors, the longest increasing run of anchors is
+// kept, and the gaps between anchors are diffed recursively. Ranges without
+// unique lines fall back to Myers.
+func patienceMatches(a, b []int, bud *budget) [][2]int {
+	var out [][2]int
+	patienceRange(a, b, 0, len(a), 0, len(b), bud, &out)
+	return out
+}
+
+func patienceRange(a, b []int, aLo, aHi, bLo, bHi int, bud *budget, out *[][2]int) {
+	aLo, aHi, bLo, bHi, suffix := trimCommon(a, b, aLo, aHi, bLo, bHi, out)
+	defer appendSuffix(aHi, bHi, suffix, out)
+
+	if aLo == aHi || bLo == bHi {
+		return
+	}
+
+	anchors := uniqueAnchors(a, b, aLo, aHi, bLo, bHi)
+	if len(anchors) == 0 {
+		myersRange(a, b, aLo, aHi, bLo, bHi, bud, out)
+		return
+	}
+
+	prevA, prevB := aLo, bLo
+	for _, anchor := range anchors {
+		patienceRange(a, b, prevA, anchor[0], prevB, anchor[1], bud, out)
+		*out = append(*out, anchor)
+		prevA, prevB = anchor[0]+1, anchor[1]+1
+	}
+	patienceRange(a, b, prevA, aHi, prevB, bHi, bud, out)
+}
+
+// uniqueAnchors returns the longest increasing sequence of (a, b) index
+// pairs whose line occurs exactly once in each range.
+func uniqueAnchors(a, b []int, aLo, aHi, bLo, bHi int) [][2]int {
+	type occurrence struct {
+		countA, countB int
+		indexA         int
+	}
+	seen := make(map[int]*occurrence)
+	for i := aLo; i < aHi; i++ {
+		occ := seen[a[i]]
+		if occ == nil {
+			occ = &occurrence{}
+			seen[a[i]] = occ
+		}
+		occ.countA++
+		occ.indexA = i
+	}
+	var candidates [][2]int
+	for j := bLo; j < bHi; j++ {
+		if occ := seen[b[j]]; occ != nil {
+			occ.countB++
+		}
+	}
+	for j := bLo; j < bHi; j++ {
+		if occ := seen[b[j]]; occ != nil && occ.countA == 1 && occ.countB == 1 {
+			candidates = append(candidates, [2]int{occ.indexA, j})
+		}
+	}
+	return longestIncreasing(candidates)
+}
+
+// longestIncreasing returns the longest subsequence of pairs (already
+// ordered by their second element) whose first elements strictly increase.
+func longestIncreasing(pairs [][2]int) [][2]int {
+	if len(pairs) == 0 {
+		return nil
+	}
+	// tails[k] is the index of the smallest tail of an increasing run of length k+1
+	var tails []int
+	prev := make([]int, len(pairs))
+	for i, p := range pairs {
+		k := sort.Search(len(tails), func(k int) bool {
+			return pairs[tails[k]][0] >= p[0]
+		})
+		if k > 0 {
+			prev[i] = tails[k-1]
+		} else {
+			prev[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	result := make([][2]int, len(tails))
+	for i, k := tails[len(tails)-1], len(tails)-1; k >= 0; i, k = prev[i], k-1 {
+		result[k] = pairs[i]
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Stats summarises the changes of a diff or of one hunk
+type Stats struct {
+	Equal    int // lines on both sides; ignored changes count as equal
+	Inserted int // lines only on the right, including the right side of Modified pairs
+	Deleted  int // lines only on the left, including the left side of Modified pairs
+	Modified int // deleted lines paired with the inserted line they were changed into
+
+	// WhitespaceOnly counts the Modified pairs whose sides differ only in
+	// whitespace, and the equal lines that matched only by ignoring it
+	WhitespaceOnly int
+
+	Hunks       int // number of hunks
+	LargestHunk int // changed lines in the biggest hunk
+}
+
+// Added returns the inserted lines that are not part of a Modified pair
+func (s Stats) Added() int {
+	return s.Inserted - s.Modified
+}
+
+// Removed returns the deleted lines that are not part of a Modified pair
+func (s Stats) Removed() int {
+	return s.Deleted - s.Modified
+}
+
+// Similarity returns the share of both files' lines that are equal, as a
+// percentage: 100 for identical files, 0 for nothing in common
+func (s Stats) Similarity() float64 {
+	total := 2*s.Equal + s.Inserted + s.Deleted
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(2*s.Equal) / float64(total)
+}
+
+// Badge formats the stats compactly, as "+12 −3 ~5 (92% similar)": lines
+// added, removed and modified
+func (s Stats) Badge() string {
+	return fmt.Sprintf("+%d −%d ~%d (%s similar)", s.Added(), s.Removed(), s.Modified, s.SimilarityText())
+}
+
+// SimilarityText formats Similarity as a whole percentage that reads 100%
+// only for identical files and 0% only for nothing in common
+func (s Stats) SimilarityText() string {
+	p := s.Similarity()
+	switch {
+	case p > 99 && p < 100:
+		return "99%"
+	case p > 0 && p < 1:
+		return "1%"
+	}
+	return fmt.Sprintf("%.0f%%", p)
+}
+
+// Stats counts the diff's lines by kind, and hunks, the diff's Hunks, by
+// size; hunks may be nil when only the line counts are wanted
+func (fd *FileDiff) Stats(hunks []Hunk) Stats {
+	s := countLines(fd.Lines, 0)
+	for _, h := range hunks {
+		s.Hunks++
+		s.LargestHunk = max(s.LargestHunk, h.changedLines())
+	}
+	return s
+}
+
+// Stats counts the hunk's lines by kind
+func (h Hunk) Stats() Stats {
+	s := countLines(h.Lines, h.Start)
+	s.Hunks, s.LargestHunk = 1, h.changedLines()
+	return s
+}
+
+func (h Hunk) changedLines() int {
+	n := 0
+	for _, line := range h.Lines {
+		if line.isChange() {
+			n++
+		}
+	}
+	return n
+}
+
+// countLines counts lines, which start at index offset of FileDiff.Lines
+func countLines(lines []DiffLine, offset int) Stats {
+	var s Stats
+	for _, line := range lines {
+		switch {
+		case line.Type == DiffEqual || line.Ignored:
+			s.Equal++
+			if line.Type == DiffEqual && line.Equalized&EqualizedWhitespace != 0 {
+				s.WhitespaceOnly++
+			}
+		case line.Type == DiffInsert:
+			s.Inserted++
+		case line.Type == DiffDelete:
+			s.Deleted++
+			if p := line.Pair - 1 - offset; line.Pair > 0 && p >= 0 && p < len(lines) {
+				s.Modified++
+				if stripSpace(line.Content) == stripSpace(lines[p].Content) {
+					s.WhitespaceOnly++
+				}
+			}
+		}
+	}
+	return s
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,81 @@
+package differ
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	lines := numbered("line", 20)
+	left := joinLines(append(lines, "the quick brown fox"))
+	kept := append(append([]string{"first"}, lines[:9]...), lines[10:]...)
+	right := joinLines(append(kept, "the quick red fox", "new"))
+
+	diff := New().CompareStrings("l", "r", left, right)
+	s := diff.Stats(diff.Hunks(3))
+	if s.Equal != 19 || s.Inserted != 3 || s.Deleted != 2 {
+		t.Errorf("equal/inserted/deleted = %d/%d/%d, want 19/3/2", s.Equal, s.Inserted, s.Deleted)
+	}
+	if s.Modified != 1 || s.Added() != 2 || s.Removed() != 1 {
+		t.Errorf("modified/added/removed = %d/%d/%d, want 1/2/1", s.Modified, s.Added(), s.Removed())
+	}
+	if s.Hunks != 3 || s.LargestHunk != 3 {
+		t.Errorf("hunks = %d, largest = %d; want 3, 3", s.Hunks, s.LargestHunk)
+	}
+	if got, want := s.Badge(), "+2 −1 ~1 (88% similar)"; got != want {
+		t.Errorf("Badge() = %q, want %q", got, want)
+	}
+}
+
+func TestStatsWhitespaceOnly(t *testing.T) {
+	left := "a\n\tindented\nb  c\ndone\n"
+	right := "a\n    indented\nb c\nDone\n"
+
+	diff := New().CompareStrings("l", "r", left, right)
+	s := diff.Stats(diff.Hunks(3))
+	if s.Modified != 3 || s.WhitespaceOnly != 2 {
+		t.Errorf("modified = %d, whitespace-only = %d; want 3, 2", s.Modified, s.WhitespaceOnly)
+	}
+
+	opts := DefaultOptions()
+	opts.Whitespace = WhitespaceIgnoreAll
+	s = NewWithOptions(opts).CompareStrings("l", "r", left, right).Stats(nil)
+	if s.Equal != 3 || s.Modified != 1 || s.WhitespaceOnly != 2 {
+		t.Errorf("ignoring whitespace: equal = %d, modified = %d, whitespace-only = %d; want 3, 1, 2",
+			s.Equal, s.Modified, s.WhitespaceOnly)
+	}
+}
+
+func TestStatsSimilarity(t *testing.T) {
+	same := New().CompareStrings("l", "r", "a\nb\n", "a\nb\n").Stats(nil)
+	if same.Similarity() != 100 || same.Hunks != 0 {
+		t.Errorf("identical files: similarity %v, %d hunks", same.Similarity(), same.Hunks)
+	}
+	if empty := (Stats{}); empty.Similarity() != 100 {
+		t.Errorf("empty files: similarity %v, want 100", empty.Similarity())
+	}
+	apart := New().CompareStrings("l", "r", "a\n", "b\n").Stats(nil)
+	if apart.Similarity() != 0 {
+		t.Errorf("disjoint files: similarity %v, want 0", apart.Similarity())
+	}
+	nearly := Stats{Equal: 999, Inserted: 1}
+	if got := nearly.SimilarityText(); got != "99%" {
+		t.Errorf("one line in 1000 changed reads %s, want 99%%", got)
+	}
+}
+
+func TestHunkStats(t *testing.T) {
+	left := numbered("line", 30)
+	right := append([]string(nil), left...)
+	right[4] = "changed5"
+	right = append(right[:25], right[26:]...)
+
+	diff := New().CompareStrings("l", "r", joinLines(left), joinLines(right))
+	hunks := diff.Hunks(3)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	if s := hunks[0].Stats(); s.Modified != 1 || s.Equal != 6 || s.LargestHunk != 2 {
+		t.Errorf("first hunk: %+v", s)
+	}
+	if s := hunks[1].Stats(); s.Modified != 0 || s.Deleted != 1 || s.Inserted != 0 {
+		t.Errorf("second hunk: %+v", s)
+	}
+}
@@ -0,0 +1,250 @@
+package differ
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StreamOptions bounds the memory a Stream uses. Each side buffers at most
+// WindowLines lines or WindowBytes bytes, whichever comes first; a single
+// line longer than WindowBytes is still held whole.
+type StreamOptions struct {
+	WindowLines int
+	WindowBytes int
+}
+
+// DefaultStreamOptions returns a window that diffs quickly even when the
+// two sides have nothing in common
+func DefaultStreamOptions() StreamOptions {
+	return StreamOptions{
+		WindowLines: 4096,
+		WindowBytes: 16 << 20,
+	}
+}
+
+// StreamPosition is where a page starts in both inputs: byte offsets for
+// seeking and 1-based line numbers for labelling
+type StreamPosition struct {
+	LeftOffset  int64
+	RightOffset int64
+	LeftLine    int
+	RightLine   int
+}
+
+// Stream compares two readers a page at a time without loading either
+// whole. A window of lines is read from each side and diffed; the page ends
+// on the last unchanged line (the anchor) that is well clear of the unread
+// input, so alignment across page boundaries matches a whole-file diff
+// whenever the files have unchanged lines in common. Move detection is off
+// for streams, since moves may span pages.
+type Stream struct {
+	differ    *Differ
+	opts      StreamOptions
+	leftFile  string
+	rightFile string
+	left      streamSide
+	right     streamSide
+}
+
+// streamSide is the buffered, not yet paged part of one input
+type streamSide struct {
+	r              *bufio.Reader
+	lines          []string // without "\n"; CRs are kept for splitEOLs
+	bytes          int
+	eof            bool
+	noFinalNewline bool
+	offset         int64 // offset of lines[0] in the input
+	line           int   // line number of lines[0]
+}
+
+// NewStream starts a streaming comparison of left and right from their
+// beginnings
+func (d *Differ) NewStream(leftFile, rightFile string, left, right io.Reader, opts StreamOptions) *Stream {
+	return d.newStream(leftFile, rightFile, left, right, StreamPosition{LeftLine: 1, RightLine: 1}, opts)
+}
+
+// ResumeStream continues a streaming comparison from a position previously
+// returned by Stream.Position, for paging back to an earlier page
+func (d *Differ) ResumeStream(leftFile, rightFile string, left, right io.ReadSeeker, pos StreamPosition, opts StreamOptions) (*Stream, error) {
+	if _, err := left.Seek(pos.LeftOffset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error seeking left file: %w", err)
+	}
+	if _, err := right.Seek(pos.RightOffset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error seeking right file: %w", err)
+	}
+	return d.newStream(leftFile, rightFile, left, right, pos, opts), nil
+}
+
+func (d *Differ) newStream(leftFile, rightFile string, left, right io.Reader, pos StreamPosition, opts StreamOptions) *Stream {
+	defaults := DefaultStreamOptions()
+	if opts.WindowLines <= 0 {
+		opts.WindowLines = defaults.WindowLines
+	}
+	if opts.WindowBytes <= 0 {
+		opts.WindowBytes = defaults.WindowBytes
+	}
+	diffOpts := d.Options
+	diffOpts.DetectMoves = false
+
+	return &Stream{
+		differ:    NewWithOptions(diffOpts),
+		opts:      opts,
+		leftFile:  leftFile,
+		rightFile: rightFile,
+		left:      streamSide{r: bufio.NewReader(left), offset: pos.LeftOffset, line: pos.LeftLine},
+		right:     streamSide{r: bufio.NewReader(right), offset: pos.RightOffset, line: pos.RightLine},
+	}
+}
+
+// Position returns where the next page starts
+func (s *Stream) Position() StreamPosition {
+	return StreamPosition{
+		LeftOffset:  s.left.offset,
+		RightOffset: s.right.offset,
+		LeftLine:    s.left.line,
+		RightLine:   s.right.line,
+	}
+}
+
+// Next returns the next page of the diff, or io.EOF once both inputs are
+// exhausted. Line numbers in the page count from the start of the files.
+func (s *Stream) Next() (*FileDiff, error) {
+	if err := s.left.fill(s.opts); err != nil {
+		return nil, fmt.Errorf("error reading left file: %w", err)
+	}
+	if err := s.right.fill(s.opts); err != nil {
+		return nil, fmt.Errorf("error reading right file: %w", err)
+	}
+	if len(s.left.lines) == 0 && len(s.right.lines) == 0 {
+		return nil, io.EOF
+	}
+
+	window := &FileDiff{
+		LeftNoFinalNewline:  s.left.eof && s.left.noFinalNewline,
+		RightNoFinalNewline: s.right.eof && s.right.noFinalNewline,
+	}
+	// compareLines strips CRs in place; the buffers must keep them
+	err := s.differ.compareLines(context.Background(), window, append([]string(nil), s.left.lines...), append([]string(nil), s.right.lines...))
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(window.Lines)
+	if !s.left.eof || !s.right.eof {
+		n = anchorCut(window.Lines, s.left.safeLines(), s.right.safeLines())
+	}
+	page := &FileDiff{
+		LeftFile:    s.leftFile,
+		RightFile:   s.rightFile,
+		Lines:       window.Lines[:n:n],
+		Approximate: window.Approximate,
+	}
+
+	var leftEOLs, rightEOLs []LineEnding
+	for i := range page.Lines {
+		line := &page.Lines[i]
+		if line.Pair > n {
+			// The other half of the pair falls on the next page
+			line.Pair, line.Spans = 0, nil
+		}
+		if line.LeftLineNum > 0 {
+			line.LeftLineNum += s.left.line - 1
+			leftEOLs = append(leftEOLs, line.EOL)
+		}
+		if line.RightLineNum > 0 {
+			line.RightLineNum += s.right.line - 1
+			rightEOLs = append(rightEOLs, line.RightLineEnding())
+		}
+		line.LineNum = line.LeftLineNum
+		if line.Type == DiffInsert {
+			line.LineNum = line.RightLineNum
+		}
+	}
+	page.LeftEOL, page.RightEOL = fileLineEnding(leftEOLs), fileLineEnding(rightEOLs)
+	page.LeftNoFinalNewline = window.LeftNoFinalNewline && len(leftEOLs) == len(s.left.lines)
+	page.RightNoFinalNewline = window.RightNoFinalNewline && len(rightEOLs) == len(s.right.lines)
+
+	s.left.consume(len(leftEOLs))
+	s.right.consume(len(rightEOLs))
+	return page, nil
+}
+
+// anchorCut returns how many diff lines make up the page: up to and
+// including the last equal line that uses no more than the given number of
+// lines from each side. Without such an anchor the page takes every line
+// within the limits, splitting a change that is larger than the window.
+func anchorCut(lines []DiffLine, leftLimit, rightLimit int) int {
+	li, ri := 0, 0
+	end, anchor := 0, 0
+	for k, line := range lines {
+		nl, nr := li, ri
+		if line.Type != DiffInsert {
+			nl++
+		}
+		if line.Type != DiffDelete {
+			nr++
+		}
+		if nl > leftLimit || nr > rightLimit {
+			break
+		}
+		li, ri, end = nl, nr, k+1
+		if line.Type == DiffEqual {
+			anchor = end
+		}
+	}
+	if anchor > 0 {
+		return anchor
+	}
+	return end
+}
+
+// fill reads lines until the window is full or the input ends
+func (s *streamSide) fill(opts StreamOptions) error {
+	for !s.eof && len(s.lines) < opts.WindowLines && s.bytes < opts.WindowBytes {
+		line, err := s.r.ReadString('\n')
+		if line != "" {
+			s.bytes += len(line)
+			if !strings.HasSuffix(line, "\n") {
+				s.lines = append(s.lines, line)
+				s.eof, s.noFinalNewline = true, true
+				return nil
+			}
+			s.lines = append(s.lines, line[:len(line)-1])
+		}
+		if err == io.EOF {
+			s.eof = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// safeLines is how many buffered lines a page may use. The last quarter of
+// a window that has more input behind it is held back, since the diff of
+// those lines may change once the following lines are seen.
+func (s *streamSide) safeLines() int {
+	if s.eof {
+		return len(s.lines)
+	}
+	return len(s.lines) - len(s.lines)/4
+}
+
+// consume drops the first n buffered lines once they have been paged
+func (s *streamSide) consume(n int) {
+	for i, line := range s.lines[:n] {
+		size := len(line) + 1
+		if s.noFinalNewline && i == len(s.lines)-1 {
+			size-- // the unterminated last line
+		}
+		s.offset += int64(size)
+		s.bytes -= size
+	}
+	s.line += n
+	s.lines = append(s.lines[:0], s.lines[n:]...)
+}
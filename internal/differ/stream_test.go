@@ -0,0 +1,147 @@
+package differ
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func streamPages(t *testing.T, s *Stream) []*FileDiff {
+	t.Helper()
+	var pages []*FileDiff
+	for {
+		page, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			return pages
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		pages = append(pages, page)
+	}
+}
+
+// rebuild reassembles both inputs from the pages of a diff
+func rebuild(pages []*FileDiff) (string, string) {
+	var left, right strings.Builder
+	for _, page := range pages {
+		for _, line := range page.Lines {
+			if line.Type != DiffInsert {
+				left.WriteString(line.Content + line.EOL.Terminator())
+			}
+			if line.Type != DiffDelete {
+				right.WriteString(line.RightText() + line.RightLineEnding().Terminator())
+			}
+		}
+	}
+	return left.String(), right.String()
+}
+
+// logDump returns n log lines with every 97th line edited on the right
+func logDump(n int) (string, string) {
+	var left, right strings.Builder
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("2024-01-01T00:00:%05d INFO request %d served in %dms\n", i, i, i%50)
+		left.WriteString(line)
+		if i%97 == 0 {
+			line = fmt.Sprintf("2024-01-01T00:00:%05d WARN request %d slow\n", i, i)
+		}
+		right.WriteString(line)
+	}
+	return left.String(), right.String()
+}
+
+func TestStreamMatchesWholeDiff(t *testing.T) {
+	left, right := logDump(2000)
+	opts := StreamOptions{WindowLines: 100}
+	pages := streamPages(t, New().NewStream("l", "r", strings.NewReader(left), strings.NewReader(right), opts))
+	if len(pages) < 10 {
+		t.Fatalf("expected the diff to be paged, got %d pages", len(pages))
+	}
+
+	whole := New().CompareStrings("l", "r", left, right)
+	var streamed []DiffLine
+	for _, page := range pages {
+		streamed = append(streamed, page.Lines...)
+	}
+	if len(streamed) != len(whole.Lines) {
+		t.Fatalf("streamed %d lines, whole diff has %d", len(streamed), len(whole.Lines))
+	}
+	for i, line := range streamed {
+		w := whole.Lines[i]
+		if line.Type != w.Type || line.Content != w.Content || line.LeftLineNum != w.LeftLineNum || line.RightLineNum != w.RightLineNum {
+			t.Fatalf("line %d: streamed %+v, whole %+v", i, line, w)
+		}
+	}
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	words := []string{"alpha", "beta", "gamma", "delta", ""}
+	gen := func() string {
+		var b strings.Builder
+		n := r.Intn(60)
+		for i := 0; i < n; i++ {
+			b.WriteString(words[r.Intn(len(words))])
+			if i < n-1 || r.Intn(4) > 0 {
+				if r.Intn(5) == 0 {
+					b.WriteString("\r\n")
+				} else {
+					b.WriteString("\n")
+				}
+			}
+		}
+		return b.String()
+	}
+
+	for iter := 0; iter < 300; iter++ {
+		left, right := gen(), gen()
+		opts := StreamOptions{WindowLines: 1 + r.Intn(8), WindowBytes: 1 + r.Intn(40)}
+		pages := streamPages(t, New().NewStream("l", "r", strings.NewReader(left), strings.NewReader(right), opts))
+		gotLeft, gotRight := rebuild(pages)
+		if gotLeft != left || gotRight != right {
+			t.Fatalf("pages do not rebuild the inputs (window %+v)\nleft:  %q\ngot:   %q\nright: %q\ngot:   %q",
+				opts, left, gotLeft, right, gotRight)
+		}
+	}
+}
+
+func TestStreamFinalNewline(t *testing.T) {
+	pages := streamPages(t, New().NewStream("l", "r", strings.NewReader("a\nb\nc"), strings.NewReader("a\nb\nc\n"), StreamOptions{WindowLines: 2}))
+	for i, page := range pages {
+		last := i == len(pages)-1
+		if page.LeftNoFinalNewline != last || page.RightNoFinalNewline {
+			t.Errorf("page %d: final newline flags %v/%v", i, page.LeftNoFinalNewline, page.RightNoFinalNewline)
+		}
+	}
+}
+
+func TestResumeStream(t *testing.T) {
+	left, right := logDump(500)
+	opts := StreamOptions{WindowLines: 64}
+	d := New()
+
+	s := d.NewStream("l", "r", strings.NewReader(left), strings.NewReader(right), opts)
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	pos := s.Position()
+	want := streamPages(t, s)
+
+	resumed, err := d.ResumeStream("l", "r", strings.NewReader(left), strings.NewReader(right), pos, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := streamPages(t, resumed)
+	if len(got) != len(want) {
+		t.Fatalf("resumed stream has %d pages, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if fmt.Sprint(got[i].Lines) != fmt.Sprint(want[i].Lines) {
+			t.Fatalf("page %d differs after resuming", i)
+		}
+	}
+}
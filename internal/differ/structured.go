@@ -0,0 +1,124 @@
+package differ
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ChangeKind classifies one change in a StructuredDiff
+type ChangeKind int
+
+const (
+	ChangeAdded    ChangeKind = iota // only on the right
+	ChangeRemoved                    // only on the left
+	ChangeModified                   // on both sides with different values
+)
+
+// Symbol returns the one-character marker of the kind, as in a line diff
+func (k ChangeKind) Symbol() string {
+	switch k {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// Change is one difference found by comparing the structure of two files:
+// where it is and the values on each side, rendered for display
+type Change struct {
+	Kind ChangeKind
+	Path string // location in the document, e.g. "$.servers[2].port"
+	Old  string // left value; empty for ChangeAdded
+	New  string // right value; empty for ChangeRemoved
+
+	// Note describes a modification that has no values to show, such as
+	// "body changed"; when set it stands in for Old and New
+	Note string
+
+	// LeftLine and RightLine are the 1-based lines the change starts on in
+	// each file, 0 when absent or not known
+	LeftLine  int
+	RightLine int
+}
+
+// String formats the change as "path: old → new", or "path: value" for
+// additions and removals
+func (c Change) String() string {
+	switch {
+	case c.Kind == ChangeAdded:
+		return fmt.Sprintf("%s: %s", c.Path, c.New)
+	case c.Kind == ChangeRemoved:
+		return fmt.Sprintf("%s: %s", c.Path, c.Old)
+	case c.Note != "":
+		return fmt.Sprintf("%s: %s", c.Path, c.Note)
+	default:
+		return fmt.Sprintf("%s: %s → %s", c.Path, c.Old, c.New)
+	}
+}
+
+// StructuredDiff is the result of comparing two files as parsed documents
+// rather than as lines, so that formatting and ordering that carry no
+// meaning are not reported. Changes describe the whole diff in the same
+// shape for every format.
+type StructuredDiff struct {
+	Format  string // name of the document format, e.g. "JSON"
+	Changes []Change
+
+	// Detail is the format's own view of the changes, for views that know
+	// how to show it: a *TableDiff for CSV and TSV files, an Outline for
+	// Markdown; nil for other formats
+	Detail Detail
+
+	// Masked is set when Changes hide secret values, as
+	// KeyValueOptions.MaskSecrets asks; the files themselves still hold them
+	Masked bool
+}
+
+// Detail is a format-specific view of the changes of a StructuredDiff
+type Detail interface {
+	// Summary describes the changes in the format's own terms, such as
+	// "Sections: 1 added, 0 removed, 2 changed"
+	Summary() string
+}
+
+// Counts returns the number of added, removed and modified changes
+func (s *StructuredDiff) Counts() (added, removed, modified int) {
+	for _, c := range s.Changes {
+		switch c.Kind {
+		case ChangeAdded:
+			added++
+		case ChangeRemoved:
+			removed++
+		case ChangeModified:
+			modified++
+		}
+	}
+	return added, removed, modified
+}
+
+// Summary describes the diff in one line, as "JSON: 1 added, 1 removed,
+// 1 changed", followed by the Detail's summary if there is one
+func (s *StructuredDiff) Summary() string {
+	added, removed, modified := s.Counts()
+	summary := fmt.Sprintf("%s: %d added, %d removed, %d changed", s.Format, added, removed, modified)
+	if s.Detail != nil {
+		summary += " • " + s.Detail.Summary()
+	}
+	return summary
+}
+
+// WriteChanges writes the changes as text, one per line marked with its
+// kind's symbol, under a header naming the files and summarising the diff
+func WriteChanges(w io.Writer, s *StructuredDiff, leftFile, rightFile string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", leftFile, rightFile)
+	fmt.Fprintf(bw, "# %s\n", s.Summary())
+	for _, c := range s.Changes {
+		fmt.Fprintf(bw, "%s %s\n", c.Kind.Symbol(), c)
+	}
+	return bw.Flush()
+}
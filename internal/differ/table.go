@@ -0,0 +1,415 @@
+package differ
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TableOptions configures CompareTable
+type TableOptions struct {
+	// KeyColumns names the header columns whose values identify a row. With
+	// none given, rows are matched on the first column when both files have
+	// it. Rows are matched by position when a key column is missing.
+	KeyColumns []string
+}
+
+// minRenameSimilarity is the share of matched rows in which a removed and
+// an added column must hold equal values to be taken as one renamed column
+const minRenameSimilarity = 0.6
+
+// TableColumn is one column of the compared tables, as it appears in either
+// file. Left and Right are its index in each file's rows, -1 where absent.
+type TableColumn struct {
+	Name    string // name in the right file, or the left one if removed
+	OldName string // name in the left file when the column was renamed
+	Left    int
+	Right   int
+}
+
+// Kind reports whether the column was added, removed, renamed (modified)
+// or kept; kept columns report ok false
+func (c TableColumn) Kind() (kind ChangeKind, ok bool) {
+	switch {
+	case c.Left < 0:
+		return ChangeAdded, true
+	case c.Right < 0:
+		return ChangeRemoved, true
+	case c.OldName != "":
+		return ChangeModified, true
+	}
+	return 0, false
+}
+
+// TableRow is a row that differs between the files. Cells hold the row's
+// values in TableDiff.Columns order: OldCells on the left, Cells on the
+// right; the side a row is missing from is nil.
+type TableRow struct {
+	Kind      ChangeKind
+	Key       string // "col=value" pairs identifying the row
+	OldCells  []string
+	Cells     []string
+	LeftLine  int // 1-based line of the row in each file, 0 if absent
+	RightLine int
+}
+
+// CellChanged reports whether column i, col, differs in a modified row.
+// Cells of added and removed columns never do: the column change is
+// reported once rather than marking every row.
+func (r TableRow) CellChanged(col TableColumn, i int) bool {
+	return r.Kind == ChangeModified && col.Left >= 0 && col.Right >= 0 && r.OldCells[i] != r.Cells[i]
+}
+
+// TableDiff compares two CSV or TSV files row by row, matching rows by
+// key rather than position so that reordering is not a change
+type TableDiff struct {
+	Columns    []TableColumn
+	KeyColumns []string   // columns rows were matched on; nil if by position
+	Rows       []TableRow // changed rows: left order, then added rows
+	Unchanged  int
+}
+
+// CompareTable compares two delimited tables with a header row. comma is
+// the field separator, ',' for CSV or '\t' for TSV. The result lists column
+// changes, including renames told apart by their values, and changed rows
+// with the cells that differ; its Changes describe the same by path.
+func CompareTable(left, right string, comma rune, opts TableOptions) (*StructuredDiff, error) {
+	a, err := parseTable(left, comma)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing left table: %w", err)
+	}
+	b, err := parseTable(right, comma)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing right table: %w", err)
+	}
+
+	keys := tableKeys(a, b, opts.KeyColumns)
+	pairs := matchRows(a, b, keys)
+	columns := matchColumns(a, b, pairs)
+
+	td := &TableDiff{Columns: columns}
+	for _, k := range keys {
+		td.KeyColumns = append(td.KeyColumns, a.header[k])
+	}
+	matchedRight := make([]bool, len(b.rows))
+	for i, row := range a.rows {
+		j := pairs[i]
+		if j < 0 {
+			td.Rows = append(td.Rows, TableRow{
+				Kind: ChangeRemoved, Key: a.rowKey(i, keys), OldCells: a.cells(row, columns, true), LeftLine: a.lines[i],
+			})
+			continue
+		}
+		matchedRight[j] = true
+		r := TableRow{
+			Kind:     ChangeModified,
+			Key:      a.rowKey(i, keys),
+			OldCells: a.cells(row, columns, true),
+			Cells:    b.cells(b.rows[j], columns, false),
+			LeftLine: a.lines[i], RightLine: b.lines[j],
+		}
+		changed := false
+		for c, col := range columns {
+			if r.CellChanged(col, c) {
+				changed = true
+				break
+			}
+		}
+		if changed {
+			td.Rows = append(td.Rows, r)
+		} else {
+			td.Unchanged++
+		}
+	}
+	for j, row := range b.rows {
+		if !matchedRight[j] {
+			td.Rows = append(td.Rows, TableRow{
+				Kind: ChangeAdded, Key: b.rowKey(j, tableKeysIn(b, a, keys)), Cells: b.cells(row, columns, false), RightLine: b.lines[j],
+			})
+		}
+	}
+
+	format := "CSV"
+	if comma == '\t' {
+		format = "TSV"
+	}
+	return &StructuredDiff{Format: format, Changes: td.changes(), Detail: td}, nil
+}
+
+// Summary describes the row and column changes and how rows were matched
+func (td *TableDiff) Summary() string {
+	var added, removed, modified int
+	for _, r := range td.Rows {
+		switch r.Kind {
+		case ChangeAdded:
+			added++
+		case ChangeRemoved:
+			removed++
+		default:
+			modified++
+		}
+	}
+	summary := fmt.Sprintf("Rows: %d unchanged, %d added, %d removed, %d changed", td.Unchanged, added, removed, modified)
+
+	var colAdded, colRemoved, colRenamed int
+	for _, c := range td.Columns {
+		switch kind, ok := c.Kind(); {
+		case !ok:
+		case kind == ChangeAdded:
+			colAdded++
+		case kind == ChangeRemoved:
+			colRemoved++
+		default:
+			colRenamed++
+		}
+	}
+	if colAdded+colRemoved+colRenamed > 0 {
+		summary += fmt.Sprintf(" • Columns: %d added, %d removed, %d renamed", colAdded, colRemoved, colRenamed)
+	}
+	if len(td.KeyColumns) > 0 {
+		summary += " • Key: " + strings.Join(td.KeyColumns, ", ")
+	} else {
+		summary += " • Matched by position"
+	}
+	return summary
+}
+
+// table is a parsed CSV or TSV file
+type table struct {
+	header []string
+	rows   [][]string
+	lines  []int // line each row starts on
+}
+
+func parseTable(content string, comma rune) (*table, error) {
+	r := csv.NewReader(strings.NewReader(content))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	t := &table{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return t, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t.header == nil {
+			t.header = record
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		t.rows = append(t.rows, record)
+		t.lines = append(t.lines, line)
+	}
+}
+
+func (t *table) column(name string) int {
+	for i, h := range t.header {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *table) cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// cells lays a row out in the order of columns
+func (t *table) cells(row []string, columns []TableColumn, left bool) []string {
+	out := make([]string, len(columns))
+	for i, col := range columns {
+		idx := col.Right
+		if left {
+			idx = col.Left
+		}
+		out[i] = t.cell(row, idx)
+	}
+	return out
+}
+
+// rowKey renders the key of row i as "col=value" pairs, or as its row
+// number when rows are matched by position
+func (t *table) rowKey(i int, keys []int) string {
+	if len(keys) == 0 {
+		return fmt.Sprintf("%d", i+1)
+	}
+	parts := make([]string, len(keys))
+	for k, col := range keys {
+		parts[k] = fmt.Sprintf("%s=%s", t.header[col], valueText(t.cell(t.rows[i], col)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// tableKeys resolves the key columns to indexes in a. It returns nil when
+// rows are to be matched by position.
+func tableKeys(a, b *table, names []string) []int {
+	if len(names) == 0 {
+		if len(a.header) == 0 || b.column(a.header[0]) < 0 {
+			return nil
+		}
+		names = a.header[:1]
+	}
+	keys := make([]int, len(names))
+	for i, name := range names {
+		keys[i] = a.column(name)
+		if keys[i] < 0 || b.column(name) < 0 {
+			return nil
+		}
+	}
+	return keys
+}
+
+// tableKeysIn maps key column indexes in a to the same columns in b
+func tableKeysIn(b, a *table, keys []int) []int {
+	out := make([]int, len(keys))
+	for i, k := range keys {
+		out[i] = b.column(a.header[k])
+	}
+	return out
+}
+
+// matchRows pairs each row of a with a row of b: by key, the n-th row with
+// a given key with the n-th on the other side, or by position without keys.
+// Unmatched rows of a map to -1.
+func matchRows(a, b *table, keys []int) []int {
+	pairs := make([]int, len(a.rows))
+	if keys == nil {
+		for i := range pairs {
+			pairs[i] = -1
+			if i < len(b.rows) {
+				pairs[i] = i
+			}
+		}
+		return pairs
+	}
+
+	bKeys := tableKeysIn(b, a, keys)
+	byKey := make(map[string][]int)
+	for j := range b.rows {
+		k := b.rowKey(j, bKeys)
+		byKey[k] = append(byKey[k], j)
+	}
+	for i := range a.rows {
+		k := a.rowKey(i, keys)
+		if js := byKey[k]; len(js) > 0 {
+			pairs[i], byKey[k] = js[0], js[1:]
+		} else {
+			pairs[i] = -1
+		}
+	}
+	return pairs
+}
+
+// matchColumns lines up the columns of both files by name, then pairs
+// leftover columns whose values agree on most matched rows as renames.
+// Columns keep the right file's order, with removed ones at the end.
+func matchColumns(a, b *table, pairs []int) []TableColumn {
+	var columns []TableColumn
+	leftUsed := make([]bool, len(a.header))
+	for j, name := range b.header {
+		i := a.column(name)
+		if i >= 0 && !leftUsed[i] {
+			leftUsed[i] = true
+		} else {
+			i = -1
+		}
+		columns = append(columns, TableColumn{Name: name, Left: i, Right: j})
+	}
+
+	for c := range columns {
+		if columns[c].Left >= 0 {
+			continue
+		}
+		best, bestScore := -1, minRenameSimilarity
+		for i := range a.header {
+			if leftUsed[i] {
+				continue
+			}
+			if score := columnSimilarity(a, b, pairs, i, columns[c].Right); score >= bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best >= 0 {
+			leftUsed[best] = true
+			columns[c].Left, columns[c].OldName = best, a.header[best]
+		}
+	}
+
+	for i, name := range a.header {
+		if !leftUsed[i] {
+			columns = append(columns, TableColumn{Name: name, Left: i, Right: -1})
+		}
+	}
+	return columns
+}
+
+// columnSimilarity is the share of matched rows whose left column i and
+// right column j hold the same non-empty value
+func columnSimilarity(a, b *table, pairs []int, i, j int) float64 {
+	matched, equal := 0, 0
+	for r, p := range pairs {
+		if p < 0 {
+			continue
+		}
+		matched++
+		if v := a.cell(a.rows[r], i); v != "" && v == b.cell(b.rows[p], j) {
+			equal++
+		}
+	}
+	if matched == 0 {
+		return 0
+	}
+	return float64(equal) / float64(matched)
+}
+
+// changes describes the table diff as path-style changes: columns first,
+// then rows, with one change per differing cell of a modified row
+func (td *TableDiff) changes() []Change {
+	var out []Change
+	for _, col := range td.Columns {
+		kind, ok := col.Kind()
+		if !ok {
+			continue
+		}
+		c := Change{Kind: kind, Path: "column"}
+		switch kind {
+		case ChangeAdded:
+			c.New = valueText(col.Name)
+		case ChangeRemoved:
+			c.Old = valueText(col.Name)
+		default:
+			c.Old, c.New = valueText(col.OldName), valueText(col.Name)
+		}
+		out = append(out, c)
+	}
+
+	for _, row := range td.Rows {
+		path := fmt.Sprintf("row[%s]", row.Key)
+		switch row.Kind {
+		case ChangeAdded:
+			out = append(out, Change{Kind: ChangeAdded, Path: path, New: strings.Join(row.Cells, ", ")})
+		case ChangeRemoved:
+			out = append(out, Change{Kind: ChangeRemoved, Path: path, Old: strings.Join(row.OldCells, ", ")})
+		default:
+			for i, col := range td.Columns {
+				if row.CellChanged(col, i) {
+					out = append(out, Change{
+						Kind: ChangeModified, Path: keyPath(path, col.Name),
+						Old: valueText(row.OldCells[i]), New: valueText(row.Cells[i]),
+					})
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package differ
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareTableMatchesRowsByKey(t *testing.T) {
+	left := "sku,name,price\nA1,Widget,10\nB2,Gadget,20\nC3,Gizmo,30\n"
+	right := "sku,name,price\nC3,Gizmo,30\nA1,Widget,12\nD4,Doohickey,40\n"
+
+	diff, err := CompareTable(left, right, ',', TableOptions{KeyColumns: []string{"sku"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`~ row[sku="A1"].price: "10" → "12"`,
+		`- row[sku="B2"]: B2, Gadget, 20`,
+		`+ row[sku="D4"]: D4, Doohickey, 40`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	td := diff.Detail.(*TableDiff)
+	if td.Unchanged != 1 || len(td.Rows) != 3 {
+		t.Fatalf("expected 1 unchanged and 3 changed rows, got %d and %d", td.Unchanged, len(td.Rows))
+	}
+	mod := td.Rows[0]
+	if mod.LeftLine != 2 || mod.RightLine != 3 {
+		t.Errorf("row lines = %d, %d; want 2, 3", mod.LeftLine, mod.RightLine)
+	}
+	for i, col := range td.Columns {
+		if got, want := mod.CellChanged(col, i), col.Name == "price"; got != want {
+			t.Errorf("column %s: CellChanged = %v, want %v", col.Name, got, want)
+		}
+	}
+}
+
+func TestCompareTableColumnChanges(t *testing.T) {
+	left := "id\tqty\tnote\n1\t5\tx\n2\t7\ty\n3\t9\tz\n"
+	right := "id\tquantity\tcolour\n1\t5\tred\n2\t7\tblue\n3\t9\tgreen\n"
+
+	diff, err := CompareTable(left, right, '\t', TableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Format != "TSV" {
+		t.Errorf("Format = %q, want TSV", diff.Format)
+	}
+	want := []string{
+		`~ column: "qty" → "quantity"`,
+		`+ column: "colour"`,
+		`- column: "note"`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if diff.Detail.(*TableDiff).Unchanged != 3 {
+		t.Errorf("column changes alone should leave rows unchanged, got %d unchanged", diff.Detail.(*TableDiff).Unchanged)
+	}
+}
+
+func TestCompareTableByPosition(t *testing.T) {
+	left := "a,b\n1,2\n3,4\n"
+	right := "x,b\n1,2\n3,5\n5,6\n"
+
+	diff, err := CompareTable(left, right, ',', TableOptions{KeyColumns: []string{"missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Detail.(*TableDiff).KeyColumns != nil {
+		t.Errorf("expected positional matching, got keys %v", diff.Detail.(*TableDiff).KeyColumns)
+	}
+	got := changeStrings(diff)
+	if len(got) == 0 || got[len(got)-1] != "+ row[3]: 5, 6" {
+		t.Errorf("unexpected changes %v", got)
+	}
+}
@@ -0,0 +1,229 @@
+package differ
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a token of a line of source code
+type TokenKind int
+
+const (
+	TokenIdent    TokenKind = iota // identifier or keyword
+	TokenNumber                    // numeric literal, e.g. 0x1F or 1.5e-3
+	TokenString                    // quoted string, character or raw string literal
+	TokenOperator                  // operator or punctuation, e.g. := or {
+	TokenSpace                     // run of whitespace
+)
+
+// Token is one token of a line of source code
+type Token struct {
+	Kind TokenKind
+	Span // byte range within the line
+}
+
+// operators are the operators of more than one character read as a single
+// token, longest first so that a prefix never shadows a longer match
+var operators = []string{
+	">>>=", "<<=", ">>=", "&^=", "**=", "...", "===", "!==", ">>>",
+	":=", "==", "!=", "<=", ">=", "&&", "||", "<<", ">>", "&^", "**", "??", "?.",
+	"++", "--", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "->", "=>", "::", "<-",
+}
+
+// TokenizeCode splits a line of source code into identifiers, numbers,
+// strings, operators and whitespace. A string left open at the end of the
+// line runs to its end, and any other character is an operator of its own.
+func TokenizeCode(line string) []Token {
+	var tokens []Token
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		kind, end := TokenOperator, i+size
+		switch {
+		case unicode.IsSpace(r):
+			kind, end = TokenSpace, scanWhile(line, end, unicode.IsSpace)
+		case isDigit(r) || r == '.' && end < len(line) && isDigit(rune(line[end])):
+			kind, end = TokenNumber, scanNumber(line, i)
+		case r == '_' || unicode.IsLetter(r):
+			kind, end = TokenIdent, scanWhile(line, end, isIdentRune)
+		case r == '"' || r == '\'' || r == '`':
+			kind, end = TokenString, scanString(line, i)
+		default:
+			for _, op := range operators {
+				if strings.HasPrefix(line[i:], op) {
+					end = i + len(op)
+					break
+				}
+			}
+		}
+		tokens = append(tokens, Token{Kind: kind, Span: Span{Start: i, End: end}})
+		i = end
+	}
+	return tokens
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanWhile returns the end of the run of runes accepted by ok from i
+func scanWhile(s string, i int, ok func(rune) bool) int {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !ok(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// scanNumber returns the end of the numeric literal starting at i: digits,
+// letters for bases, suffixes and hex digits, separators, a decimal point,
+// and the sign of an exponent
+func scanNumber(s string, i int) int {
+	hex := strings.HasPrefix(s[i:], "0x") || strings.HasPrefix(s[i:], "0X")
+	for end := i; end < len(s); end++ {
+		switch c := s[end]; {
+		case c == '.' || c == '_' || c < utf8.RuneSelf && isIdentRune(rune(c)):
+		case (c == '+' || c == '-') && end > i && exponent(s[end-1], hex):
+		default:
+			return end
+		}
+	}
+	return len(s)
+}
+
+// exponent reports whether c introduces the exponent of a decimal or, for
+// hex, a hexadecimal floating-point literal
+func exponent(c byte, hex bool) bool {
+	if hex {
+		return c == 'p' || c == 'P'
+	}
+	return c == 'e' || c == 'E'
+}
+
+// scanString returns the end of the string literal opening at i. Backslash
+// escapes the next character except in a backquoted raw string.
+func scanString(s string, i int) int {
+	quote := s[i]
+	for end := i + 1; end < len(s); end++ {
+		switch s[end] {
+		case '\\':
+			if quote != '`' {
+				end++
+			}
+		case quote:
+			return end + 1
+		}
+	}
+	return len(s)
+}
+
+// maxTokenBlockBytes bounds the size of a changed block whose lines are
+// compared as one token stream; larger blocks compare their modified pairs
+// line by line, as the other granularities do
+const maxTokenBlockBytes = maxInlineBytes
+
+// annotateTokens fills in the spans of every changed line by diffing the
+// tokens of each block of deleted lines against those of the inserted lines
+// that replaced it, so a token that moved to the next line, as when a call
+// is wrapped, is not reported as changed. Lines whose tokens all matched get
+// an empty, non-nil slice of spans.
+func annotateTokens(lines []DiffLine) {
+	for start := 0; start < len(lines); {
+		if lines[start].Type == DiffEqual {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && lines[end].Type != DiffEqual {
+			end++
+		}
+		annotateTokenBlock(lines, start, end)
+		start = end
+	}
+}
+
+// annotateTokenBlock annotates the changed lines in lines[start:end].
+// Ignored and moved lines are left alone, since they are shown as such.
+func annotateTokenBlock(lines []DiffLine, start, end int) {
+	var dels, ins []int
+	size := 0
+	for k := start; k < end; k++ {
+		if lines[k].Ignored || lines[k].MoveID != 0 {
+			continue
+		}
+		if lines[k].Type == DiffDelete {
+			dels = append(dels, k)
+		} else {
+			ins = append(ins, k)
+		}
+		size += len(lines[k].Content)
+	}
+	if len(dels) == 0 || len(ins) == 0 {
+		return
+	}
+	if size > maxTokenBlockBytes {
+		for _, k := range dels {
+			if j := lines[k].Pair - 1; j >= 0 {
+				lines[k].Spans, lines[j].Spans = InlineDiff(lines[k].Content, lines[j].Content, GranularityToken)
+			}
+		}
+		return
+	}
+
+	leftTokens, leftTexts := blockTokens(lines, dels)
+	rightTokens, rightTexts := blockTokens(lines, ins)
+	a, b := internLines(leftTexts, rightTexts, identity)
+	leftMatched := make([]bool, len(leftTokens))
+	rightMatched := make([]bool, len(rightTokens))
+	for _, mt := range myersMatches(a, b) {
+		leftMatched[mt[0]] = true
+		rightMatched[mt[1]] = true
+	}
+	setTokenSpans(lines, leftTokens, leftMatched)
+	setTokenSpans(lines, rightTokens, rightMatched)
+}
+
+// lineToken is a token of one of the lines of a block
+type lineToken struct {
+	line int // index into the diff's lines
+	Span
+}
+
+// blockTokens returns the tokens of the given lines in order, and their texts
+func blockTokens(lines []DiffLine, indexes []int) ([]lineToken, []string) {
+	var tokens []lineToken
+	var texts []string
+	for _, k := range indexes {
+		content := lines[k].Content
+		for _, tok := range TokenizeCode(content) {
+			tokens = append(tokens, lineToken{line: k, Span: tok.Span})
+			texts = append(texts, content[tok.Start:tok.End])
+		}
+	}
+	return tokens, texts
+}
+
+// setTokenSpans sets each line's spans from its unmatched tokens
+func setTokenSpans(lines []DiffLine, tokens []lineToken, matched []bool) {
+	for i := 0; i < len(tokens); {
+		k := tokens[i].line
+		j := i
+		var spans []Span
+		for j < len(tokens) && tokens[j].line == k {
+			spans = append(spans, tokens[j].Span)
+			j++
+		}
+		lines[k].Spans = changedSpans(lines[k].Content, spans, matched[i:j], GranularityToken)
+		if lines[k].Spans == nil {
+			lines[k].Spans = []Span{}
+		}
+		i = j
+	}
+}
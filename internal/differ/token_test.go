@@ -0,0 +1,88 @@
+package differ
+
+import "testing"
+
+func codeTokenTexts(line string, tokens []Token) []string {
+	out := make([]string, len(tokens))
+	for i, tok := range tokens {
+		out[i] = line[tok.Start:tok.End]
+	}
+	return out
+}
+
+func TestTokenizeCode(t *testing.T) {
+	line := `x := fmt.Sprintf("a \"b\"", 1.5e-3) // 0x1F`
+	tokens := TokenizeCode(line)
+	want := []string{"x", " ", ":=", " ", "fmt", ".", "Sprintf", "(", `"a \"b\""`, ",", " ", "1.5e-3", ")",
+		" ", "/", "/", " ", "0x1F"}
+	if got := codeTokenTexts(line, tokens); !equalStrings(got, want) {
+		t.Fatalf("tokens = %q, want %q", got, want)
+	}
+	kinds := map[string]TokenKind{"x": TokenIdent, ":=": TokenOperator, `"a \"b\""`: TokenString, "1.5e-3": TokenNumber, " ": TokenSpace}
+	for _, tok := range tokens {
+		text := line[tok.Start:tok.End]
+		if kind, ok := kinds[text]; ok && tok.Kind != kind {
+			t.Errorf("%q has kind %d, want %d", text, tok.Kind, kind)
+		}
+	}
+}
+
+func TestTokenizeCodeOpenString(t *testing.T) {
+	line := "s := `raw \\"
+	tokens := TokenizeCode(line)
+	last := tokens[len(tokens)-1]
+	if last.Kind != TokenString || line[last.Start:] != "`raw \\" {
+		t.Errorf("last token = %q, want the open raw string", line[last.Start:last.End])
+	}
+}
+
+func TestInlineDiffToken(t *testing.T) {
+	left := `total := count * 10`
+	right := `total := counter * 12`
+	l, r := InlineDiff(left, right, GranularityToken)
+
+	if got := spanTexts(left, l); !equalStrings(got, []string{"count", "10"}) {
+		t.Errorf("left spans = %q, want [\"count\" \"10\"]", got)
+	}
+	if got := spanTexts(right, r); !equalStrings(got, []string{"counter", "12"}) {
+		t.Errorf("right spans = %q, want [\"counter\" \"12\"]", got)
+	}
+}
+
+func TestTokenModeAcrossLines(t *testing.T) {
+	left := "start\nresult := compute(alpha, beta, gamma)\nend\n"
+	right := "start\nresult := compute(alpha,\n\tbeta, delta)\nend\n"
+
+	opts := DefaultOptions()
+	opts.Granularity = GranularityToken
+	diff := NewWithOptions(opts).CompareStrings("l", "r", left, right)
+
+	var changed []string
+	for _, line := range diff.Lines {
+		if line.Type == DiffEqual {
+			continue
+		}
+		if line.Spans == nil {
+			t.Errorf("changed line %q has no spans", line.Content)
+		}
+		changed = append(changed, spanTexts(line.Content, line.Spans)...)
+	}
+	if want := []string{" ", "gamma", "\t", "delta"}; !equalStrings(changed, want) {
+		t.Errorf("changed tokens = %q, want %q", changed, want)
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	for _, g := range Granularities {
+		got, err := ParseGranularity(g.String())
+		if err != nil || got != g {
+			t.Errorf("ParseGranularity(%q) = %v, %v", g, got, err)
+		}
+	}
+	if _, err := ParseGranularity("line"); err == nil {
+		t.Error("expected an error for an unknown granularity")
+	}
+	if GranularityToken.Next() != GranularityWord {
+		t.Error("token should cycle back to word")
+	}
+}
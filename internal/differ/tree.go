@@ -0,0 +1,231 @@
+package differ
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// treeComparer compares parsed documents made of map[string]any objects,
+// []any arrays and scalars (string, json.Number, bool, nil), as decoded
+// from JSON or converted from YAML, and records the changes by path
+type treeComparer struct {
+	keys []string // fields that identify array elements; see JSONOptions.ArrayKeys
+	diff *StructuredDiff
+}
+
+// topNull stands in for a document that is just null, which would
+// otherwise be indistinguishable from a missing document
+type topNull struct{}
+
+func (c *treeComparer) add(kind ChangeKind, path string, old, new any) {
+	change := Change{Kind: kind, Path: path}
+	if kind != ChangeAdded {
+		change.Old = valueText(old)
+	}
+	if kind != ChangeRemoved {
+		change.New = valueText(new)
+	}
+	c.diff.Changes = append(c.diff.Changes, change)
+}
+
+// compareRoots compares two whole documents, either of which may be
+// missing (nil)
+func (c *treeComparer) compareRoots(path string, a, b any) {
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		c.add(ChangeAdded, path, nil, b)
+	case b == nil:
+		c.add(ChangeRemoved, path, a, nil)
+	default:
+		c.compare(path, a, b)
+	}
+}
+
+func (c *treeComparer) compare(path string, a, b any) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			c.compareObjects(path, av, bv)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			c.compareArrays(path, av, bv)
+			return
+		}
+	}
+	if !valuesEqual(a, b) {
+		c.add(ChangeModified, path, a, b)
+	}
+}
+
+// compareObjects reports keys in sorted order, so the result does not
+// depend on the order either side lists them in
+func (c *treeComparer) compareObjects(path string, a, b map[string]any) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		p := keyPath(path, k)
+		switch {
+		case !inB:
+			c.add(ChangeRemoved, p, av, nil)
+		case !inA:
+			c.add(ChangeAdded, p, nil, bv)
+		default:
+			c.compare(p, av, bv)
+		}
+	}
+}
+
+func (c *treeComparer) compareArrays(path string, a, b []any) {
+	if key := c.arrayKey(a, b); key != "" {
+		c.compareKeyedArrays(path, key, a, b)
+		return
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(b):
+			c.add(ChangeRemoved, p, a[i], nil)
+		case i >= len(a):
+			c.add(ChangeAdded, p, nil, b[i])
+		default:
+			c.compare(p, a[i], b[i])
+		}
+	}
+}
+
+// compareKeyedArrays matches elements by their key field: left elements in
+// their order, then right elements that have no left counterpart
+func (c *treeComparer) compareKeyedArrays(path, key string, a, b []any) {
+	right := make(map[string]any, len(b))
+	for _, e := range b {
+		right[valueText(e.(map[string]any)[key])] = e
+	}
+	left := make(map[string]bool, len(a))
+	for _, e := range a {
+		id := valueText(e.(map[string]any)[key])
+		left[id] = true
+		p := fmt.Sprintf("%s[%s=%s]", path, key, id)
+		if be, ok := right[id]; ok {
+			c.compare(p, e, be)
+		} else {
+			c.add(ChangeRemoved, p, e, nil)
+		}
+	}
+	for _, e := range b {
+		id := valueText(e.(map[string]any)[key])
+		if !left[id] {
+			c.add(ChangeAdded, fmt.Sprintf("%s[%s=%s]", path, key, id), nil, e)
+		}
+	}
+}
+
+// arrayKey returns the first of the configured keys that identifies every
+// element of both arrays, or "" if none does
+func (c *treeComparer) arrayKey(a, b []any) string {
+	if len(a) == 0 && len(b) == 0 {
+		return ""
+	}
+	for _, key := range c.keys {
+		if identifies(key, a) && identifies(key, b) {
+			return key
+		}
+	}
+	return ""
+}
+
+// identifies reports whether every element is an object with a distinct
+// scalar value for key
+func identifies(key string, elems []any) bool {
+	seen := make(map[string]bool, len(elems))
+	for _, e := range elems {
+		obj, ok := e.(map[string]any)
+		if !ok {
+			return false
+		}
+		v, ok := obj[key]
+		if !ok {
+			return false
+		}
+		switch v.(type) {
+		case string, json.Number, bool:
+		default:
+			return false
+		}
+		id := valueText(v)
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+// valuesEqual compares scalars, treating numbers that are written
+// differently but have the same value (1, 1.0, 1e0) as equal
+func valuesEqual(a, b any) bool {
+	an, aNum := a.(json.Number)
+	bn, bNum := b.(json.Number)
+	if aNum && bNum {
+		ar, aok := new(big.Rat).SetString(an.String())
+		br, bok := new(big.Rat).SetString(bn.String())
+		if aok && bok {
+			return ar.Cmp(br) == 0
+		}
+		return an == bn
+	}
+	return valueText(a) == valueText(b)
+}
+
+// valueText renders a value as compact JSON with sorted keys
+func valueText(v any) string {
+	if _, ok := v.(topNull); ok {
+		return "null"
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// keyPath appends an object key to a path: ".name" for keys that look like
+// identifiers, `["odd key"]` for the rest. At the start of a path with no
+// root marker the dot is dropped.
+func keyPath(path, key string) string {
+	ident := key != ""
+	for i, r := range key {
+		if !(r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && (r >= '0' && r <= '9' || r == '-'))) {
+			ident = false
+			break
+		}
+	}
+	switch {
+	case !ident:
+		return path + "[" + strconv.Quote(key) + "]"
+	case path == "":
+		return key
+	default:
+		return path + "." + key
+	}
+}
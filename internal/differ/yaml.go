@@ -0,0 +1,229 @@
+package differ
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLOptions configures CompareYAML
+type YAMLOptions struct {
+	// ListKeys are mapping keys that identify list items, as
+	// JSONOptions.ArrayKeys does for JSON arrays
+	ListKeys []string
+}
+
+// DefaultYAMLOptions matches list items on the usual identity keys
+func DefaultYAMLOptions() YAMLOptions {
+	return YAMLOptions{ListKeys: []string{"id", "name", "key"}}
+}
+
+// CompareYAML compares two YAML files by value and reports changes by key
+// path, such as "server.port: 8080 → 3000". Indentation, key order, quoting
+// and comments are ignored, and anchors and merge keys are expanded. Files
+// with several documents are compared document by document, with paths
+// prefixed by the document number. It fails if either side is not valid
+// YAML, or its aliases expand to too many values.
+func CompareYAML(left, right string, opts YAMLOptions) (*StructuredDiff, error) {
+	a, err := parseYAML(left)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing left YAML: %w", err)
+	}
+	b, err := parseYAML(right)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing right YAML: %w", err)
+	}
+
+	c := &treeComparer{keys: opts.ListKeys, diff: &StructuredDiff{Format: "YAML"}}
+	multi := len(a) > 1 || len(b) > 1
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ad, bd any
+		if i < len(a) {
+			ad = a[i]
+		}
+		if i < len(b) {
+			bd = b[i]
+		}
+		root := ""
+		if multi {
+			root = fmt.Sprintf("doc[%d]", i+1)
+		}
+		c.compareRoots(root, ad, bd)
+	}
+	for i := range c.diff.Changes {
+		if c.diff.Changes[i].Path == "" {
+			c.diff.Changes[i].Path = "(document)"
+		}
+	}
+	return c.diff, nil
+}
+
+// parseYAML decodes every document in content
+func parseYAML(content string) ([]any, error) {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	var docs []any
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := (&yamlConverter{}).value(&node)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			v = topNull{}
+		}
+		docs = append(docs, v)
+	}
+}
+
+// maxYAMLValues caps the values a YAML document may expand to once its
+// aliases are resolved, so that a few lines of aliases of aliases cannot
+// exhaust memory
+const maxYAMLValues = 1 << 20
+
+// maxYAMLAliasDepth caps how deeply aliases may refer to anchors that use
+// aliases themselves; it also stops an anchor that contains itself
+const maxYAMLAliasDepth = 64
+
+// yamlConverter converts the nodes of one document, counting the values it
+// produces and the aliases it is expanding against the caps above
+type yamlConverter struct {
+	values, aliases int
+}
+
+// value converts a YAML node to the representation treeComparer works on:
+// map[string]any, []any, json.Number, string, bool or nil
+func (c *yamlConverter) value(n *yaml.Node) (any, error) {
+	if c.values++; c.values > maxYAMLValues {
+		return nil, fmt.Errorf("line %d: document expands to more than %d values through its aliases", n.Line, maxYAMLValues)
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return c.value(n.Content[0])
+
+	case yaml.AliasNode:
+		if c.aliases >= maxYAMLAliasDepth {
+			return nil, fmt.Errorf("line %d: aliases nested more than %d deep, or an anchor that contains itself", n.Line, maxYAMLAliasDepth)
+		}
+		c.aliases++
+		defer func() { c.aliases-- }()
+		return c.value(n.Alias)
+
+	case yaml.SequenceNode:
+		items := make([]any, len(n.Content))
+		for i, item := range n.Content {
+			v, err := c.value(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		var merged []map[string]any
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			keyNode, valueNode := n.Content[i], n.Content[i+1]
+			v, err := c.value(valueNode)
+			if err != nil {
+				return nil, err
+			}
+			if keyNode.Kind == yaml.ScalarNode && keyNode.ShortTag() == "!!merge" {
+				merged = append(merged, mergeSources(v)...)
+				continue
+			}
+			key := keyNode.Value
+			if keyNode.Kind != yaml.ScalarNode {
+				k, err := c.value(keyNode)
+				if err != nil {
+					return nil, err
+				}
+				key = valueText(k)
+			}
+			m[key] = v
+		}
+		// Keys from "<<" fill in only what the mapping does not set itself
+		for _, src := range merged {
+			for k, v := range src {
+				if _, ok := m[k]; !ok {
+					m[k] = v
+				}
+			}
+		}
+		return m, nil
+
+	case yaml.ScalarNode:
+		return yamlScalar(n)
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
+
+// mergeSources returns the mappings named by a merge key's value
+func mergeSources(v any) []map[string]any {
+	switch src := v.(type) {
+	case map[string]any:
+		return []map[string]any{src}
+	case []any:
+		var out []map[string]any
+		for _, e := range src {
+			if m, ok := e.(map[string]any); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// yamlScalar resolves a scalar by its tag, so that 8080 and 0x1F90 are the
+// same number and "yes" stays a string as YAML 1.2 says
+func yamlScalar(n *yaml.Node) (any, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "!!int":
+		var i int64
+		if err := n.Decode(&i); err != nil {
+			var u uint64
+			if err := n.Decode(&u); err != nil {
+				return nil, err
+			}
+			return json.Number(strconv.FormatUint(u, 10)), nil
+		}
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	case "!!float":
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return nil, err
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			// .inf and .nan have no JSON form; keep them as written
+			return n.Value, nil
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	default:
+		return n.Value, nil
+	}
+}
@@ -0,0 +1,106 @@
+package differ
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompareYAMLIgnoresLayout(t *testing.T) {
+	left := "# v1\nserver:\n  host: \"0.0.0.0\"\n  port: 8080\nlist: [a, b]\n"
+	right := "list:\n    - a\n    - 'b'\nserver: {port: 0x1F90, host: 0.0.0.0}\n"
+
+	diff, err := CompareYAML(left, right, DefaultYAMLOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("expected no changes, got %v", changeStrings(diff))
+	}
+}
+
+func TestCompareYAMLKeyPaths(t *testing.T) {
+	left := "server:\n  port: 8080\n  timeout: 30\nmonitoring:\n  enabled: false\n"
+	right := "server:\n  port: 3000\n  workers: 4\nmonitoring:\n  enabled: true\n"
+
+	diff, err := CompareYAML(left, right, DefaultYAMLOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"~ monitoring.enabled: false → true",
+		"~ server.port: 8080 → 3000",
+		"- server.timeout: 30",
+		"+ server.workers: 4",
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareYAMLListsByKey(t *testing.T) {
+	left := "services:\n  - name: web\n    image: nginx:1.24\n  - name: db\n    image: postgres:15\n"
+	right := "services:\n  - name: db\n    image: postgres:16\n  - name: web\n    image: nginx:1.24\n"
+
+	diff, err := CompareYAML(left, right, YAMLOptions{ListKeys: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`~ services[name="db"].image: "postgres:15" → "postgres:16"`}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompareYAMLAnchorsAndMergeKeys(t *testing.T) {
+	left := "base: &base\n  retries: 3\n  timeout: 5\njob:\n  <<: *base\n  timeout: 10\n"
+	right := "base:\n  retries: 3\n  timeout: 5\njob:\n  retries: 3\n  timeout: 10\n"
+
+	diff, err := CompareYAML(left, right, DefaultYAMLOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("expanded anchors should compare equal, got %v", changeStrings(diff))
+	}
+}
+
+func TestCompareYAMLAliasBomb(t *testing.T) {
+	// Each level lists the one before ten times: 10^9 values in all
+	bomb := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	for i, prev := 1, "a"; i < 9; i++ {
+		name := string(rune('a' + i))
+		bomb += fmt.Sprintf("%s: &%s [%s]\n", name, name, strings.TrimSuffix(strings.Repeat("*"+prev+", ", 10), ", "))
+		prev = name
+	}
+	if _, err := CompareYAML("a: 1\n", bomb, DefaultYAMLOptions()); err == nil || !strings.Contains(err.Error(), "expands to more than") {
+		t.Errorf("expected the expansion to be refused, got %v", err)
+	}
+
+	if _, err := CompareYAML("a: &x\n  b: *x\n", "a: 1\n", DefaultYAMLOptions()); err == nil || !strings.Contains(err.Error(), "contains itself") {
+		t.Errorf("expected an anchor that contains itself to be refused, got %v", err)
+	}
+}
+
+func TestCompareYAMLMultipleDocuments(t *testing.T) {
+	left := "kind: Service\nport: 80\n---\nkind: Deployment\nreplicas: 2\n"
+	right := "kind: Service\nport: 80\n---\nkind: Deployment\nreplicas: 3\n---\nkind: ConfigMap\n"
+
+	diff, err := CompareYAML(left, right, DefaultYAMLOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"~ doc[2].replicas: 2 → 3",
+		`+ doc[3]: {"kind":"ConfigMap"}`,
+	}
+	if got := changeStrings(diff); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompareYAMLInvalid(t *testing.T) {
+	if _, err := CompareYAML("a: 1\n", "a: [1\n", DefaultYAMLOptions()); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
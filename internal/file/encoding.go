@@ -0,0 +1,239 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset is a character encoding that files are transcoded from for
+// comparison and back to when saved
+type Charset int
+
+const (
+	CharsetUTF8        Charset = iota
+	CharsetUTF16LE             // little-endian UTF-16, as written by Windows tools
+	CharsetUTF16BE             // big-endian UTF-16
+	CharsetWindows1252         // legacy 8-bit Western European, a superset of Latin-1
+)
+
+// String returns the conventional name of the charset
+func (c Charset) String() string {
+	switch c {
+	case CharsetUTF16LE:
+		return "UTF-16LE"
+	case CharsetUTF16BE:
+		return "UTF-16BE"
+	case CharsetWindows1252:
+		return "Windows-1252"
+	default:
+		return "UTF-8"
+	}
+}
+
+// bom returns the byte order mark of the charset, or nil if it has none
+func (c Charset) bom() []byte {
+	switch c {
+	case CharsetUTF8:
+		return []byte{0xEF, 0xBB, 0xBF}
+	case CharsetUTF16LE:
+		return []byte{0xFF, 0xFE}
+	case CharsetUTF16BE:
+		return []byte{0xFE, 0xFF}
+	default:
+		return nil
+	}
+}
+
+// Encoding is how a text file is stored on disk: its charset and whether it
+// starts with a byte order mark. The zero value is UTF-8 without a BOM.
+type Encoding struct {
+	Charset Charset
+	BOM     bool
+}
+
+// String returns a label such as "UTF-8" or "UTF-16LE BOM"
+func (e Encoding) String() string {
+	if e.BOM {
+		return e.Charset.String() + " BOM"
+	}
+	return e.Charset.String()
+}
+
+// IsUTF8 reports whether content in this encoding is stored as is
+func (e Encoding) IsUTF8() bool {
+	return e == Encoding{}
+}
+
+// DetectEncoding guesses the encoding of raw file content: a byte order mark
+// decides if there is one; otherwise content whose every other byte is
+// mostly NUL is UTF-16, valid UTF-8 is UTF-8, and anything else is taken to
+// be Windows-1252. Binary content is reported as UTF-8 and left alone.
+func DetectEncoding(data []byte) Encoding {
+	for _, cs := range []Charset{CharsetUTF8, CharsetUTF16LE, CharsetUTF16BE} {
+		if bytes.HasPrefix(data, cs.bom()) {
+			return Encoding{Charset: cs, BOM: true}
+		}
+	}
+
+	sample := data
+	if len(sample) > sniffLen {
+		sample = trimPartialRune(sample[:sniffLen])
+	}
+	// NULs are valid UTF-8, so UTF-16 has to be recognised first
+	if cs, ok := guessUTF16(sample); ok {
+		return Encoding{Charset: cs}
+	}
+	if utf8.Valid(sample) {
+		return Encoding{}
+	}
+	if IsBinary(data) {
+		return Encoding{}
+	}
+	return Encoding{Charset: CharsetWindows1252}
+}
+
+// guessUTF16 recognises UTF-16 without a BOM by its NUL bytes: text that is
+// mostly ASCII has a NUL as the high byte of nearly every code unit, and
+// almost never as the low byte
+func guessUTF16(sample []byte) (Charset, bool) {
+	units := len(sample) / 2
+	if units < 2 {
+		return 0, false
+	}
+	var zeroEven, zeroOdd int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			zeroEven++
+		}
+		if sample[i+1] == 0 {
+			zeroOdd++
+		}
+	}
+	switch {
+	case zeroOdd*4 >= units && zeroEven*20 <= zeroOdd:
+		return CharsetUTF16LE, true
+	case zeroEven*4 >= units && zeroOdd*20 <= zeroEven:
+		return CharsetUTF16BE, true
+	}
+	return 0, false
+}
+
+// Decode transcodes raw content in this encoding to UTF-8, dropping the BOM
+func (e Encoding) Decode(data []byte) (string, error) {
+	if e.BOM {
+		data = bytes.TrimPrefix(data, e.Charset.bom())
+	}
+
+	switch e.Charset {
+	case CharsetUTF16LE, CharsetUTF16BE:
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("odd number of bytes in %s content", e.Charset)
+		}
+		order := e.byteOrder()
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		return string(utf16.Decode(units)), nil
+
+	case CharsetWindows1252:
+		runes := make([]rune, len(data))
+		for i, c := range data {
+			runes[i] = rune(c)
+			if c >= 0x80 && c < 0xA0 && windows1252[c-0x80] != 0 {
+				runes[i] = windows1252[c-0x80]
+			}
+		}
+		return string(runes), nil
+
+	default:
+		return string(data), nil
+	}
+}
+
+// Encode transcodes UTF-8 text to this encoding, adding the BOM. It fails if
+// the text has characters the charset cannot represent.
+func (e Encoding) Encode(text string) ([]byte, error) {
+	var out []byte
+	if e.BOM {
+		out = append(out, e.Charset.bom()...)
+	}
+
+	switch e.Charset {
+	case CharsetUTF16LE, CharsetUTF16BE:
+		order := e.byteOrder()
+		for _, u := range utf16.Encode([]rune(text)) {
+			out = order.AppendUint16(out, u)
+		}
+
+	case CharsetWindows1252:
+		for _, r := range text {
+			c, ok := encodeWindows1252(r)
+			if !ok {
+				return nil, fmt.Errorf("%q cannot be encoded in %s", r, e.Charset)
+			}
+			out = append(out, c)
+		}
+
+	default:
+		out = append(out, text...)
+	}
+	return out, nil
+}
+
+func (e Encoding) byteOrder() interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+} {
+	if e.Charset == CharsetUTF16BE {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// windows1252 maps bytes 0x80–0x9F to the characters Windows-1252 puts
+// there; 0 marks the five unassigned bytes, which decode as the C1 controls
+// of Latin-1 so that every byte round-trips
+var windows1252 = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+func encodeWindows1252(r rune) (byte, bool) {
+	switch {
+	case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+		return byte(r), true
+	case r < 0xA0:
+		return byte(r), windows1252[r-0x80] == 0
+	}
+	for i, m := range windows1252 {
+		if m == r {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
+
+// decodeContent detects the encoding of raw file content and transcodes it
+// to UTF-8. Content that does not survive the round trip back to its
+// detected encoding unchanged is kept as it is, so saving never alters bytes
+// the user did not edit.
+func decodeContent(data []byte) (string, Encoding) {
+	enc := DetectEncoding(data)
+	if enc.IsUTF8() {
+		return string(data), enc
+	}
+	text, err := enc.Decode(data)
+	if err != nil {
+		return string(data), Encoding{}
+	}
+	if back, err := enc.Encode(text); err != nil || !bytes.Equal(back, data) {
+		return string(data), Encoding{}
+	}
+	return text, enc
+}
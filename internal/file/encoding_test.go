@@ -0,0 +1,71 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    Encoding
+	}{
+		{"ascii", "hello\n", Encoding{}},
+		{"utf8", "naïve café\n", Encoding{}},
+		{"utf8 bom", "\xEF\xBB\xBFhello", Encoding{CharsetUTF8, true}},
+		{"utf16le bom", "\xFF\xFEh\x00i\x00", Encoding{CharsetUTF16LE, true}},
+		{"utf16be bom", "\xFE\xFF\x00h\x00i", Encoding{CharsetUTF16BE, true}},
+		{"utf16le", "h\x00e\x00l\x00l\x00o\x00\n\x00", Encoding{CharsetUTF16LE, false}},
+		{"utf16be", "\x00h\x00e\x00l\x00l\x00o\x00\n", Encoding{CharsetUTF16BE, false}},
+		{"latin1", "caf\xE9 cr\xE8me\n", Encoding{CharsetWindows1252, false}},
+		{"binary", "PK\x03\x04\x00\x00\xFF\x01\x02", Encoding{}},
+	}
+	for _, tc := range cases {
+		if got := DetectEncoding([]byte(tc.content)); got != tc.want {
+			t.Errorf("%s: DetectEncoding = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeContentRoundTrips(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		text string
+	}{
+		{"utf8 bom", "\xEF\xBB\xBFcafé\n", "café\n"},
+		{"utf16le bom", "\xFF\xFEc\x00a\x00f\x00\xE9\x00\n\x00", "café\n"},
+		{"utf16be surrogate pair", "\xFE\xFF\xD8\x3D\xDE\x00\x00\n", "😀\n"},
+		{"windows-1252", "\x93quoted\x94 \x80 \x81\n", "“quoted” € \u0081\n"},
+	}
+	for _, tc := range cases {
+		text, enc := decodeContent([]byte(tc.raw))
+		if text != tc.text {
+			t.Errorf("%s: decoded %q, want %q", tc.name, text, tc.text)
+			continue
+		}
+		back, err := enc.Encode(text)
+		if err != nil {
+			t.Errorf("%s: Encode error: %v", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(back, []byte(tc.raw)) {
+			t.Errorf("%s: re-encoded %q, want %q", tc.name, back, tc.raw)
+		}
+	}
+}
+
+func TestEncodeUnrepresentable(t *testing.T) {
+	if _, err := (Encoding{Charset: CharsetWindows1252}).Encode("snowman ☃"); err == nil {
+		t.Error("expected an error encoding ☃ in Windows-1252")
+	}
+}
+
+func TestOddUTF16IsKeptAsIs(t *testing.T) {
+	raw := "\xFF\xFEh\x00i\x00!"
+	text, enc := decodeContent([]byte(raw))
+	if text != raw || !enc.IsUTF8() {
+		t.Errorf("content that cannot round-trip should be kept: got %q as %v", text, enc)
+	}
+}
@@ -1,6 +1,9 @@
 package file
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -14,16 +17,51 @@ type FileInfo struct {
 	Name     string
 	IsDir    bool
 	Size     int64
-	Content  string
+	Content  string // transcoded to UTF-8 from Encoding
 	Children []*FileInfo
+
+	// Encoding is how the content is stored on disk. Open and CopyTo give
+	// the original bytes back.
+	Encoding Encoding
+
+	// Large marks a file bigger than Manager.LargeFileSize. Its Content is
+	// not loaded; read it from disk with Open.
+	Large bool
+
+	// Binary is set when the content does not look like text (see IsBinary)
+	Binary  bool
+	sniffed bool
+}
+
+// NewFileInfo describes a file whose content is already in memory, such as
+// one read from git
+func NewFileInfo(path, name, content string) *FileInfo {
+	fi := &FileInfo{
+		Path:    path,
+		Name:    name,
+		Size:    int64(len(content)),
+		Content: content,
+		Binary:  IsBinary([]byte(content)),
+		sniffed: true,
+	}
+	if !fi.Binary {
+		fi.Content, fi.Encoding = decodeContent([]byte(content))
+	}
+	return fi
 }
 
+// DefaultLargeFileSize is the size above which files are streamed from disk
+// rather than loaded into memory
+const DefaultLargeFileSize = 64 << 20
+
 // Manager handles file operations
-type Manager struct{}
+type Manager struct {
+	LargeFileSize int64
+}
 
 // New creates a new file manager
 func New() *Manager {
-	return &Manager{}
+	return &Manager{LargeFileSize: DefaultLargeFileSize}
 }
 
 // LoadPath loads a file or directory and returns FileInfo
@@ -46,12 +84,26 @@ func (m *Manager) LoadPath(path string) (*FileInfo, error) {
 			return nil, fmt.Errorf("failed to load directory %s: %w", path, err)
 		}
 		fileInfo.Children = children
+	} else if m.LargeFileSize > 0 && info.Size() > m.LargeFileSize {
+		head, err := readHead(path, sniffLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		fileInfo.Large = true
+		fileInfo.Binary, fileInfo.sniffed = IsBinary(head), true
+		if !fileInfo.Binary {
+			fileInfo.Encoding = DetectEncoding(head)
+		}
 	} else {
 		content, err := m.readFile(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file %s: %w", path, err)
 		}
 		fileInfo.Content = content
+		fileInfo.Binary, fileInfo.sniffed = IsBinary([]byte(content)), true
+		if !fileInfo.Binary {
+			fileInfo.Content, fileInfo.Encoding = decodeContent([]byte(content))
+		}
 	}
 
 	return fileInfo, nil
@@ -99,20 +151,130 @@ func (m *Manager) readFile(filePath string) (string, error) {
 	return string(content), nil
 }
 
+// readHead reads up to n bytes from the start of a file
+func readHead(filePath string, n int) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, n)
+	read, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:read], nil
+}
+
 // GetReader returns an io.Reader for the file content
 func (fi *FileInfo) GetReader() io.Reader {
 	return strings.NewReader(fi.Content)
 }
 
-// IsTextFile checks if the file appears to be a text file based on its extension
+// Open returns a reader for the file content as stored, in its original
+// encoding; large files are read from disk
+func (fi *FileInfo) Open() (io.ReadSeekCloser, error) {
+	if fi.Large {
+		return os.Open(fi.Path)
+	}
+	if !fi.Encoding.IsUTF8() {
+		// decodeContent only accepts encodings that round-trip
+		data, err := fi.Encoding.Encode(fi.Content)
+		if err != nil {
+			return nil, err
+		}
+		return contentReader{bytes.NewReader(data)}, nil
+	}
+	return contentReader{strings.NewReader(fi.Content)}, nil
+}
+
+// OpenText returns a reader for the file content as UTF-8 text, to be
+// diffed as it is read. Large files are read from disk as they are, so it
+// fails for one stored in another encoding: transcoding would move the
+// byte offsets that a paged diff resumes from.
+func (fi *FileInfo) OpenText() (io.ReadSeekCloser, error) {
+	if !fi.Large {
+		return contentReader{strings.NewReader(fi.Content)}, nil
+	}
+	if fi.Encoding.Charset != CharsetUTF8 {
+		return nil, fmt.Errorf("%s is a large %s file; only UTF-8 files can be compared page by page", fi.Name, fi.Encoding.Charset)
+	}
+	return os.Open(fi.Path)
+}
+
+// contentReader reads content that is already in memory
+type contentReader struct {
+	io.ReadSeeker
+}
+
+func (contentReader) Close() error { return nil }
+
+// SameContent reports whether the two files have identical content. known
+// is false when that cannot be told without reading a large file.
+func (fi *FileInfo) SameContent(other *FileInfo) (same, known bool) {
+	if fi.Large || other.Large {
+		if fi.Size != other.Size {
+			return false, true
+		}
+		return false, false
+	}
+	return fi.Content == other.Content && fi.Encoding == other.Encoding, true
+}
+
+// SameText reports whether the two files hold the same text, whatever their
+// encodings; false when that cannot be told without reading a large file
+func (fi *FileInfo) SameText(other *FileInfo) bool {
+	return !fi.Large && !other.Large && fi.Content == other.Content
+}
+
+// Hash returns the hex SHA-256 of the file content
+func (fi *FileInfo) Hash() (string, error) {
+	r, err := fi.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CopyTo writes the file content to path
+func (fi *FileInfo) CopyTo(path string) error {
+	src, err := fi.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// IsTextFile checks if the file appears to be a text file. Files whose
+// content has been seen are judged by it; otherwise the extension decides.
 func (fi *FileInfo) IsTextFile() bool {
 	if fi.IsDir {
 		return false
 	}
+	if fi.sniffed {
+		return !fi.Binary
+	}
 
 	ext := strings.ToLower(filepath.Ext(fi.Path))
 	textExts := []string{
-		".txt", ".md", ".go", ".js", ".ts", ".py", ".java", ".c", ".cpp", ".h", ".hpp",
+		".txt", ".log", ".md", ".go", ".js", ".ts", ".py", ".java", ".c", ".cpp", ".h", ".hpp",
 		".css", ".html", ".htm", ".xml", ".json", ".yaml", ".yml", ".toml", ".csv",
 		".sh", ".bash", ".zsh", ".fish", ".ps1", ".bat", ".cmd", ".sql",
 		".php", ".rb", ".rs", ".swift", ".kt", ".cs", ".vb", ".fs",
@@ -191,20 +353,25 @@ type FileComparison struct {
 	Source       FileSource
 }
 
+// IsBinary reports whether either side of the comparison is a binary file
+func (fc *FileComparison) IsBinary() bool {
+	return (fc.LeftFile != nil && fc.LeftFile.Binary) || (fc.RightFile != nil && fc.RightFile.Binary)
+}
+
 // FindCommonFiles finds files that exist in both file trees with the same relative path
 func FindCommonFiles(left, right *FileInfo) map[string][2]*FileInfo {
 	leftFiles := make(map[string]*FileInfo)
 	rightFiles := make(map[string]*FileInfo)
 
-	// Get all text files and create relative path maps
-	for _, file := range left.GetTextFiles() {
+	// Get all files, text and binary, and create relative path maps
+	for _, file := range left.GetAllFiles() {
 		rel, err := filepath.Rel(left.Path, file.Path)
 		if err == nil {
 			leftFiles[rel] = file
 		}
 	}
 
-	for _, file := range right.GetTextFiles() {
+	for _, file := range right.GetAllFiles() {
 		rel, err := filepath.Rel(right.Path, file.Path)
 		if err == nil {
 			rightFiles[rel] = file
@@ -227,15 +394,15 @@ func FindAllFiles(left, right *FileInfo) map[string]*FileComparison {
 	leftFiles := make(map[string]*FileInfo)
 	rightFiles := make(map[string]*FileInfo)
 
-	// Get all text files and create relative path maps
-	for _, file := range left.GetTextFiles() {
+	// Get all files, text and binary, and create relative path maps
+	for _, file := range left.GetAllFiles() {
 		rel, err := filepath.Rel(left.Path, file.Path)
 		if err == nil {
 			leftFiles[rel] = file
 		}
 	}
 
-	for _, file := range right.GetTextFiles() {
+	for _, file := range right.GetAllFiles() {
 		rel, err := filepath.Rel(right.Path, file.Path)
 		if err == nil {
 			rightFiles[rel] = file
@@ -1,8 +1,10 @@
 package file
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -264,6 +266,90 @@ func TestLoadPathDirectory(t *testing.T) {
 	}
 }
 
+func TestLoadPathLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.log")
+	if err := os.WriteFile(path, []byte("0123456789\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+	m.LargeFileSize = 5
+	fi, err := m.LoadPath(path)
+	if err != nil {
+		t.Fatalf("LoadPath error: %v", err)
+	}
+	if !fi.Large || fi.Content != "" {
+		t.Fatalf("large file should not be loaded: Large=%v Content=%q", fi.Large, fi.Content)
+	}
+
+	r, err := fi.Open()
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer r.Close()
+	content, _ := io.ReadAll(r)
+	if string(content) != "0123456789\n" {
+		t.Errorf("Open content mismatch: got %q", content)
+	}
+
+	if _, known := fi.SameContent(fi); known {
+		t.Error("large files of equal size cannot be told apart without reading them")
+	}
+}
+
+func TestOpenTextLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(path, []byte("\xFF\xFEh\x00i\x00\n\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+	m.LargeFileSize = 4
+	fi, err := m.LoadPath(path)
+	if err != nil {
+		t.Fatalf("LoadPath error: %v", err)
+	}
+	if _, err := fi.OpenText(); err == nil || !strings.Contains(err.Error(), "UTF-16LE") {
+		t.Errorf("expected a large UTF-16 file to be refused, got %v", err)
+	}
+
+	small, err := New().LoadPath(path)
+	if err != nil {
+		t.Fatalf("LoadPath error: %v", err)
+	}
+	r, err := small.OpenText()
+	if err != nil {
+		t.Fatalf("OpenText error: %v", err)
+	}
+	defer r.Close()
+	if content, _ := io.ReadAll(r); string(content) != "hi\n" {
+		t.Errorf("OpenText should give the text as UTF-8, got %q", content)
+	}
+}
+
+func TestFindAllFilesSniffsContent(t *testing.T) {
+	m := New()
+	root := makeTree(t, map[string]string{
+		"module.wasm": "\x00asm\x01\x00\x00\x00",
+		"notes.txt":   "PK\x03\x04\x00\x00 not really text",
+	})
+	info, _ := m.LoadPath(root)
+	all := FindAllFiles(info, info)
+
+	wasm, ok := all["module.wasm"]
+	if !ok {
+		t.Fatal("binary files should be listed")
+	}
+	if !wasm.LeftFile.Binary {
+		t.Error("module.wasm should be detected as binary")
+	}
+	if notes := all["notes.txt"]; notes == nil || notes.LeftFile.IsTextFile() {
+		t.Error("a binary named .txt should not count as text")
+	}
+}
+
 func TestLoadPathNonExistent(t *testing.T) {
 	m := New()
 	_, err := m.LoadPath("/nonexistent/path/does/not/exist.txt")
@@ -289,3 +375,39 @@ func keys2(m map[string][2]*FileInfo) []string {
 	}
 	return out
 }
+
+func TestLoadPathTranscodes(t *testing.T) {
+	dir := t.TempDir()
+	raw := []byte("\xFF\xFEh\x00\xE9\x00\r\x00\n\x00")
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := New().LoadPath(path)
+	if err != nil {
+		t.Fatalf("LoadPath error: %v", err)
+	}
+	if fi.Binary {
+		t.Fatal("UTF-16 text should not be binary")
+	}
+	if fi.Content != "hé\r\n" || fi.Encoding != (Encoding{CharsetUTF16LE, true}) {
+		t.Errorf("got %q as %v, want UTF-8 text from UTF-16LE BOM", fi.Content, fi.Encoding)
+	}
+
+	copyPath := filepath.Join(dir, "copy.txt")
+	if err := fi.CopyTo(copyPath); err != nil {
+		t.Fatalf("CopyTo error: %v", err)
+	}
+	if got, _ := os.ReadFile(copyPath); string(got) != string(raw) {
+		t.Errorf("copy should keep the original bytes: got %q", got)
+	}
+
+	utf8 := NewFileInfo("b", "b", "hé\r\n")
+	if same, _ := fi.SameContent(utf8); same {
+		t.Error("files in different encodings are not the same content")
+	}
+	if !fi.SameText(utf8) {
+		t.Error("files with the same text should compare as SameText")
+	}
+}
@@ -0,0 +1,76 @@
+package file
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// sniffLen is how much of a file is examined to tell text from binary, the
+// same amount git looks at
+const sniffLen = 8000
+
+// maxControlRatio is the share of control bytes above which content that is
+// not valid UTF-8 is taken to be binary rather than a legacy 8-bit encoding
+const maxControlRatio = 0.1
+
+// byte order marks of the Unicode encodings, longest first so UTF-32LE is
+// not mistaken for UTF-16LE
+var boms = [][]byte{
+	{0x00, 0x00, 0xFE, 0xFF}, // UTF-32BE
+	{0xFF, 0xFE, 0x00, 0x00}, // UTF-32LE
+	{0xEF, 0xBB, 0xBF},       // UTF-8
+	{0xFE, 0xFF},             // UTF-16BE
+	{0xFF, 0xFE},             // UTF-16LE
+}
+
+// IsBinary reports whether content looks like binary data rather than text,
+// judging by its first sniffLen bytes. Content starting with a byte order
+// mark or looking like UTF-16 is text; otherwise a NUL byte means binary, as
+// do many control bytes in content that is not valid UTF-8.
+func IsBinary(content []byte) bool {
+	sample := content
+	if len(sample) > sniffLen {
+		sample = sample[:sniffLen]
+	}
+
+	for _, bom := range boms {
+		if bytes.HasPrefix(sample, bom) {
+			return false
+		}
+	}
+	if _, ok := guessUTF16(sample); ok {
+		return false
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	// A multi-byte rune cut off by the sample limit is still valid text
+	if len(content) > sniffLen {
+		sample = trimPartialRune(sample)
+	}
+	if utf8.Valid(sample) {
+		return false
+	}
+
+	control := 0
+	for _, c := range sample {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != '\b' && c != 0x1B {
+			control++
+		}
+	}
+	return float64(control) > maxControlRatio*float64(len(sample))
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of b
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
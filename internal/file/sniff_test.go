@@ -0,0 +1,29 @@
+package file
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		binary  bool
+	}{
+		{"empty", "", false},
+		{"ascii", "package main\n", false},
+		{"utf8", "naïve café ✓\n", false},
+		{"nul", "PK\x03\x04\x00\x00", true},
+		{"utf16 bom", "\xFF\xFEh\x00i\x00", false},
+		{"utf8 bom", "\xEF\xBB\xBFhello", false},
+		{"latin1", "caf\xE9 cr\xE8me\n", false},
+		{"control bytes", "\x01\x02\x03\x04\xFF\x05\x06", true},
+		{"rune cut at sniff limit", strings.Repeat("a", sniffLen-1) + "é and more", false},
+	}
+	for _, tc := range cases {
+		if got := IsBinary([]byte(tc.content)); got != tc.binary {
+			t.Errorf("%s: IsBinary = %v, want %v", tc.name, got, tc.binary)
+		}
+	}
+}
@@ -62,6 +62,37 @@ func (cs *ChangeSelection) ToggleDeletion(lineIndex int) {
 	}
 }
 
+// ToggleHunk flips every change in the hunk together. If any change in the
+// hunk is selected the whole hunk is deselected; otherwise it is selected.
+func (cs *ChangeSelection) ToggleHunk(diff *differ.FileDiff, hunk differ.Hunk) {
+	selected := cs.IsHunkSelected(diff, hunk)
+	for i := hunk.Start; i < hunk.End; i++ {
+		switch diff.Lines[i].Type {
+		case differ.DiffInsert:
+			cs.ApplyInsertions[i] = !selected
+		case differ.DiffDelete:
+			cs.ApplyDeletions[i] = !selected
+		}
+	}
+}
+
+// IsHunkSelected returns whether any change in the hunk is selected
+func (cs *ChangeSelection) IsHunkSelected(diff *differ.FileDiff, hunk differ.Hunk) bool {
+	for i := hunk.Start; i < hunk.End; i++ {
+		switch diff.Lines[i].Type {
+		case differ.DiffInsert:
+			if cs.IsInsertionSelected(i) {
+				return true
+			}
+		case differ.DiffDelete:
+			if cs.IsDeletionSelected(i) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // IsInsertionSelected returns whether an insertion at the given line index is selected
 func (cs *ChangeSelection) IsInsertionSelected(lineIndex int) bool {
 	return cs.ApplyInsertions[lineIndex]
@@ -96,9 +127,10 @@ func (cs *ChangeSelection) SelectNone(diff *differ.FileDiff) {
 	}
 }
 
-// ApplyToLeft applies selected changes to create a merged version starting from the left file
+// ApplyToLeft applies selected changes to create a merged version starting from the left file.
+// Every line keeps its own line ending, including a missing final newline.
 func (m *Merger) ApplyToLeft(diff *differ.FileDiff, selection *ChangeSelection) *MergeResult {
-	var result strings.Builder
+	var result mergedText
 	applied := 0
 	skipped := 0
 
@@ -106,14 +138,12 @@ func (m *Merger) ApplyToLeft(diff *differ.FileDiff, selection *ChangeSelection)
 		switch line.Type {
 		case differ.DiffEqual:
 			// Always include equal lines
-			result.WriteString(line.Content)
-			result.WriteString("\n")
+			result.writeLine(line.Content, line.EOL)
 
 		case differ.DiffDelete:
 			// Only include deleted lines if NOT selected for deletion
 			if !selection.IsDeletionSelected(i) {
-				result.WriteString(line.Content)
-				result.WriteString("\n")
+				result.writeLine(line.Content, line.EOL)
 				skipped++
 			} else {
 				applied++
@@ -122,8 +152,7 @@ func (m *Merger) ApplyToLeft(diff *differ.FileDiff, selection *ChangeSelection)
 		case differ.DiffInsert:
 			// Only include inserted lines if selected for insertion
 			if selection.IsInsertionSelected(i) {
-				result.WriteString(line.Content)
-				result.WriteString("\n")
+				result.writeLine(line.Content, line.EOL)
 				applied++
 			} else {
 				skipped++
@@ -131,14 +160,8 @@ func (m *Merger) ApplyToLeft(diff *differ.FileDiff, selection *ChangeSelection)
 		}
 	}
 
-	// Remove trailing newline if present
-	content := result.String()
-	if strings.HasSuffix(content, "\n") {
-		content = content[:len(content)-1]
-	}
-
 	return &MergeResult{
-		Content: content,
+		Content: result.String(),
 		Applied: applied,
 		Skipped: skipped,
 	}
@@ -146,22 +169,21 @@ func (m *Merger) ApplyToLeft(diff *differ.FileDiff, selection *ChangeSelection)
 
 // ApplyToRight applies selected changes to create a merged version starting from the right file
 func (m *Merger) ApplyToRight(diff *differ.FileDiff, selection *ChangeSelection) *MergeResult {
-	var result strings.Builder
+	var result mergedText
 	applied := 0
 	skipped := 0
 
 	for i, line := range diff.Lines {
 		switch line.Type {
 		case differ.DiffEqual:
-			// Always include equal lines
-			result.WriteString(line.Content)
-			result.WriteString("\n")
+			// Always include equal lines, keeping the right file's own text
+			// when the sides only matched under a relaxed comparison
+			result.writeLine(line.RightText(), line.RightLineEnding())
 
 		case differ.DiffInsert:
 			// Only include inserted lines if NOT selected for insertion
 			if !selection.IsInsertionSelected(i) {
-				result.WriteString(line.Content)
-				result.WriteString("\n")
+				result.writeLine(line.Content, line.EOL)
 				skipped++
 			} else {
 				applied++
@@ -170,8 +192,7 @@ func (m *Merger) ApplyToRight(diff *differ.FileDiff, selection *ChangeSelection)
 		case differ.DiffDelete:
 			// Only include deleted lines if selected for deletion
 			if selection.IsDeletionSelected(i) {
-				result.WriteString(line.Content)
-				result.WriteString("\n")
+				result.writeLine(line.Content, line.EOL)
 				applied++
 			} else {
 				skipped++
@@ -179,19 +200,36 @@ func (m *Merger) ApplyToRight(diff *differ.FileDiff, selection *ChangeSelection)
 		}
 	}
 
-	// Remove trailing newline if present
-	content := result.String()
-	if strings.HasSuffix(content, "\n") {
-		content = content[:len(content)-1]
-	}
-
 	return &MergeResult{
-		Content: content,
+		Content: result.String(),
 		Applied: applied,
 		Skipped: skipped,
 	}
 }
 
+// mergedText builds merged content line by line, each line with its own
+// line ending. A line that has none, such as an unterminated last line, is
+// only left so when nothing follows it; otherwise it is ended like the line
+// after it.
+type mergedText struct {
+	strings.Builder
+	open bool // the last line written has no terminator yet
+}
+
+func (t *mergedText) writeLine(content string, eol differ.LineEnding) {
+	term := eol.Terminator()
+	if t.open {
+		if term == "" {
+			t.WriteString("\n")
+		} else {
+			t.WriteString(term)
+		}
+	}
+	t.WriteString(content)
+	t.WriteString(term)
+	t.open = term == ""
+}
+
 // GetSelectedStats returns statistics about selected changes
 func (cs *ChangeSelection) GetSelectedStats(diff *differ.FileDiff) (int, int, int, int) {
 	selectedInsertions := 0
@@ -237,7 +275,10 @@ func (m *Merger) CreateMergePreview(diff *differ.FileDiff, selection *ChangeSele
 		result = m.ApplyToRight(diff, selection)
 	}
 
-	lines := strings.Split(result.Content, "\n")
+	lines := strings.Split(strings.TrimSuffix(result.Content, "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
 	maxLines := 10
 	if len(lines) > maxLines {
 		for i := 0; i < maxLines; i++ {
@@ -241,3 +241,100 @@ func TestMergeSymmetry(t *testing.T) {
 			leftResult.Content, rightResult.Content)
 	}
 }
+
+// ---- Relaxed comparison -----------------------------------------------------
+
+func TestApplyToRightKeepsRightWhitespace(t *testing.T) {
+	// Lines equal only after ignoring whitespace must keep each file's own
+	// text so a merge never rewrites indentation behind the user's back.
+	m := New()
+	d := differ.NewWithOptions(differ.Options{Whitespace: differ.WhitespaceIgnoreAll})
+	diff := d.CompareStrings("l", "r", "\tkeep\nold\n", "    keep\nnew\n")
+	sel := NewChangeSelection(diff)
+
+	if got := m.ApplyToRight(diff, sel).Content; got != "    keep\nold\n" {
+		t.Errorf("ApplyToRight = %q, want %q", got, "    keep\nold\n")
+	}
+	if got := m.ApplyToLeft(diff, sel).Content; got != "\tkeep\nnew\n" {
+		t.Errorf("ApplyToLeft = %q, want %q", got, "\tkeep\nnew\n")
+	}
+}
+
+// ---- Hunks ------------------------------------------------------------------
+
+func TestToggleHunk(t *testing.T) {
+	m := New()
+	diff := makeDiff("a\nb\nc\nd\ne\nf\ng\nh\n", "a\nB\nc\nd\ne\nf\ng\nH\n")
+	hunks := diff.Hunks(0)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	sel := NewChangeSelection(diff)
+
+	sel.ToggleHunk(diff, hunks[0])
+	if sel.IsHunkSelected(diff, hunks[0]) {
+		t.Error("first hunk should be deselected after toggle")
+	}
+	if !sel.IsHunkSelected(diff, hunks[1]) {
+		t.Error("second hunk should be unaffected")
+	}
+	if got := m.ApplyToLeft(diff, sel).Content; got != "a\nb\nc\nd\ne\nf\ng\nH\n" {
+		t.Errorf("ApplyToLeft = %q", got)
+	}
+
+	sel.ToggleHunk(diff, hunks[0])
+	if !sel.IsHunkSelected(diff, hunks[0]) {
+		t.Error("toggling again should reselect the hunk")
+	}
+}
+
+// ---- Line endings ----------------------------------------------------------
+
+func TestMergeKeepsLineEndings(t *testing.T) {
+	m := New()
+	diff := makeDiff("a\r\nb\r\nc", "a\r\nB\r\nc")
+	sel := NewChangeSelection(diff)
+
+	if got := m.ApplyToLeft(diff, sel).Content; got != "a\r\nB\r\nc" {
+		t.Errorf("ApplyToLeft = %q, want CRLFs and no final newline kept", got)
+	}
+	sel.SelectNone(diff)
+	if got := m.ApplyToLeft(diff, sel).Content; got != "a\r\nb\r\nc" {
+		t.Errorf("ApplyToLeft(none) = %q, want the left file unchanged", got)
+	}
+}
+
+func TestMergeTerminatesUnterminatedLines(t *testing.T) {
+	m := New()
+	d := differ.NewWithOptions(differ.Options{IgnoreEOL: true})
+	diff := d.CompareStrings("left", "right", "a\nb", "a\nb\nc\n")
+	sel := NewChangeSelection(diff)
+	sel.SelectAll(diff)
+	if got := m.ApplyToLeft(diff, sel).Content; got != "a\nb\nc\n" {
+		t.Errorf("ApplyToLeft with IgnoreEOL = %q, want %q", got, "a\nb\nc\n")
+	}
+
+	// Keep the unterminated b but take the inserted c
+	diff = makeDiff("a\nb", "a\nb\nc\n")
+	sel = NewChangeSelection(diff)
+	sel.SelectNone(diff)
+	for i, line := range diff.Lines {
+		if line.Type == differ.DiffInsert && line.Content == "c" {
+			sel.ToggleInsertion(i)
+		}
+	}
+	if got := m.ApplyToLeft(diff, sel).Content; got != "a\nb\nc\n" {
+		t.Errorf("ApplyToLeft keeping b = %q, want %q", got, "a\nb\nc\n")
+	}
+
+	// Bring the unterminated b into the right file ahead of its own lines
+	sel.SelectNone(diff)
+	for i, line := range diff.Lines {
+		if line.Type == differ.DiffDelete {
+			sel.ToggleDeletion(i)
+		}
+	}
+	if got := m.ApplyToRight(diff, sel).Content; got != "a\nb\nb\nc\n" {
+		t.Errorf("ApplyToRight = %q, want %q", got, "a\nb\nb\nc\n")
+	}
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"golang-fileCmp/internal/differ"
+	"golang-fileCmp/internal/file"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// badgePlaceholder stands in for a badge that is still being computed
+const badgePlaceholder = "…"
+
+// cachedBadge is the file list badge of a pair of files, with the contents
+// and diff options it was computed from; pending until the background
+// diff computing it reports back. outline summarises the sections that
+// changed, for files whose comparator has an outline.
+type cachedBadge struct {
+	left, right, options string
+	badge, outline       string
+	pending              bool
+}
+
+// current reports whether the badge was computed for the files' present
+// contents under options
+func (b cachedBadge) current(fc *file.FileComparison, options string) bool {
+	return b.left == fc.LeftFile.Content && b.right == fc.RightFile.Content && b.options == options
+}
+
+// badgeMsg carries a badge computed in the background; err is set when the
+// diff was cancelled before it finished
+type badgeMsg struct {
+	fc    *file.FileComparison
+	badge cachedBadge
+	err   error
+}
+
+// hasBadge reports whether the file list shows a line diff badge for fc:
+// text files on both sides that differ and are small enough to load
+func hasBadge(fc *file.FileComparison) bool {
+	return fc.Source == file.SourceBoth && !fc.IsBinary() && !fc.LeftFile.Large && !fc.RightFile.Large &&
+		fc.LeftFile.Content != fc.RightFile.Content
+}
+
+// fileBadge summarises the line diff of a pair of differing files as
+// "+12 −3 ~5 (92% similar)", for the file list: the cached badge, or a
+// placeholder until requestBadges has computed it; "" for other files
+func (m *Model) fileBadge(fc *file.FileComparison) string {
+	if !hasBadge(fc) {
+		return ""
+	}
+	b, ok := m.badges[fc]
+	if !ok || b.pending || !b.current(fc, m.diffOptionsLabel()) {
+		return badgePlaceholder
+	}
+	return b.badge
+}
+
+// fileOutline summarises the sections of a pair of differing documents with
+// an outline, such as Markdown files, that changed, for the file list: the
+// cached summary, or "" until requestBadges has computed it and for other
+// files
+func (m *Model) fileOutline(fc *file.FileComparison) string {
+	if !hasBadge(fc) {
+		return ""
+	}
+	b, ok := m.badges[fc]
+	if !ok || b.pending || !b.current(fc, m.diffOptionsLabel()) {
+		return ""
+	}
+	return b.outline
+}
+
+// requestBadges returns a command that computes, in the background, the
+// badges and outline summaries of the files the file list shows that have
+// none for their current contents and options; nil when there are none to
+// compute
+func (m *Model) requestBadges() tea.Cmd {
+	if m.viewMode != ViewModeFileSelect || len(m.allFiles) == 0 {
+		return nil
+	}
+	files, start, end, _ := m.fileListWindow()
+	options := m.diffOptionsLabel()
+
+	var cmds []tea.Cmd
+	for _, relPath := range files[start:end] {
+		fc := m.allFiles[relPath]
+		if !hasBadge(fc) {
+			continue
+		}
+		if b, ok := m.badges[fc]; ok && b.current(fc, options) {
+			continue // computed, or on its way
+		}
+		if m.badges == nil {
+			m.badges = make(map[*file.FileComparison]cachedBadge)
+		}
+		if m.cancelBadges == nil {
+			m.badgeCtx, m.cancelBadges = context.WithCancel(context.Background())
+		}
+		b := cachedBadge{left: fc.LeftFile.Content, right: fc.RightFile.Content, options: options, pending: true}
+		m.badges[fc] = b
+		outliner, _ := m.comparators.Lookup(fc.RelativePath, fc.LeftFile.Content).(differ.OutlineComparator)
+		cmds = append(cmds, computeBadge(m.badgeCtx, differ.NewWithOptions(m.differ.Options), outliner, fc, b))
+	}
+	return tea.Batch(cmds...)
+}
+
+// computeBadge returns a command that diffs the contents recorded in b, and
+// compares their sections with outliner unless it is nil
+func computeBadge(ctx context.Context, d *differ.Differ, outliner differ.OutlineComparator, fc *file.FileComparison, b cachedBadge) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := d.CompareContext(ctx, fc.LeftFile.Path, fc.RightFile.Path, b.left, b.right)
+		if err != nil {
+			return badgeMsg{fc: fc, err: err}
+		}
+		b.badge, b.pending = diff.Stats(nil).Badge(), false
+		if outliner != nil {
+			outline, err := outliner.CompareOutline(ctx, b.left, b.right)
+			if ctx.Err() != nil {
+				return badgeMsg{fc: fc, err: ctx.Err()}
+			}
+			if err == nil && len(outline) > 0 {
+				added, removed, changed, _, _ := outline.Counts()
+				b.outline = fmt.Sprintf("[sections +%d -%d ~%d]", added, removed, changed)
+			}
+		}
+		return badgeMsg{fc: fc, badge: b}
+	}
+}
+
+// badgeLoaded stores a badge computed in the background, unless the files
+// were replaced meanwhile. A cancelled one is forgotten so that it is
+// requested again when next shown.
+func (m *Model) badgeLoaded(msg badgeMsg) {
+	if msg.err != nil {
+		if b, ok := m.badges[msg.fc]; ok && b.pending {
+			delete(m.badges, msg.fc)
+		}
+		return
+	}
+	if b, ok := m.badges[msg.fc]; ok && b.pending {
+		m.badges[msg.fc] = msg.badge
+	}
+}
+
+// stopBadges cancels the badges being computed, as when a diff is opened
+// and needs the CPU, or the files they describe are replaced
+func (m *Model) stopBadges() {
+	if m.cancelBadges != nil {
+		m.cancelBadges()
+		m.badgeCtx, m.cancelBadges = nil, nil
+	}
+}
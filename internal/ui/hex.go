@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang-fileCmp/internal/differ"
+	"golang-fileCmp/internal/file"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHexBytes is how much of each binary file the hex view compares
+const maxHexBytes = 1 << 20
+
+// hexView is an xxd-style comparison of two binary files, shown instead of
+// a line diff
+type hexView struct {
+	diff                *differ.ByteDiff
+	leftHash, rightHash string // hex SHA-256 of the whole file; "" if missing
+	leftSize, rightSize int64
+	truncated           bool // only the first maxHexBytes of a side were compared
+	changed             int  // cells that differ
+
+	// Offsets of each row's first byte, for rows of perRow cells
+	perRow              int
+	leftOffs, rightOffs []int64
+}
+
+// startHexDiff compares a binary pair in the background
+func (m *Model) startHexDiff(fc *file.FileComparison) tea.Cmd {
+	ctx, seq, d := m.beginDiff()
+	left, right := fc.LeftFile, fc.RightFile
+	header := &differ.FileDiff{LeftFile: sidePath(left), RightFile: sidePath(right)}
+
+	return func() tea.Msg {
+		hex, err := loadHexView(ctx, d, left, right)
+		return diffLoadedMsg{seq: seq, diff: header, hex: hex, err: err}
+	}
+}
+
+func sidePath(fi *file.FileInfo) string {
+	if fi == nil {
+		return "<file not found>"
+	}
+	return fi.Path
+}
+
+func loadHexView(ctx context.Context, d *differ.Differ, left, right *file.FileInfo) (*hexView, error) {
+	h := &hexView{}
+	leftBytes, err := h.readSide(left, &h.leftHash, &h.leftSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading left file: %w", err)
+	}
+	rightBytes, err := h.readSide(right, &h.rightHash, &h.rightSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading right file: %w", err)
+	}
+
+	h.diff, err = d.CompareBytes(ctx, leftBytes, rightBytes)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range h.diff.Cells {
+		if c.Changed() {
+			h.changed++
+		}
+	}
+	return h, nil
+}
+
+// readSide hashes a file and reads the part of it the hex view shows
+func (h *hexView) readSide(fi *file.FileInfo, hash *string, size *int64) ([]byte, error) {
+	if fi == nil {
+		return nil, nil
+	}
+	var err error
+	if *hash, err = fi.Hash(); err != nil {
+		return nil, err
+	}
+	*size = fi.Size
+
+	r, err := fi.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxHexBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxHexBytes {
+		data = data[:maxHexBytes]
+		h.truncated = true
+	}
+	return data, nil
+}
+
+// hexBytesPerRow fits two panes of offset, hex and ASCII columns in the
+// window: each byte takes 3 columns of hex and 1 of ASCII
+func (m *Model) hexBytesPerRow() int {
+	for _, n := range []int{16, 8} {
+		if 2*(10+4*n+2)+3 <= m.windowWidth {
+			return n
+		}
+	}
+	return 4
+}
+
+// rows returns the number of rows of perRow cells, computing the byte
+// offsets each row starts at on both sides
+func (h *hexView) rows(perRow int) int {
+	if h.perRow != perRow {
+		n := (len(h.diff.Cells) + perRow - 1) / perRow
+		h.perRow = perRow
+		h.leftOffs, h.rightOffs = make([]int64, n), make([]int64, n)
+		var left, right int64
+		for i, c := range h.diff.Cells {
+			if i%perRow == 0 {
+				h.leftOffs[i/perRow], h.rightOffs[i/perRow] = left, right
+			}
+			if c.Left >= 0 {
+				left++
+			}
+			if c.Right >= 0 {
+				right++
+			}
+		}
+	}
+	return len(h.leftOffs)
+}
+
+// rowCells returns the cells shown on a row
+func (h *hexView) rowCells(row int) []differ.ByteCell {
+	start := row * h.perRow
+	return h.diff.Cells[start:min(start+h.perRow, len(h.diff.Cells))]
+}
+
+func rowChanged(cells []differ.ByteCell) bool {
+	for _, c := range cells {
+		if c.Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// jumpToHexChange moves the cursor to the start of the next (dir > 0) or
+// previous (dir < 0) run of rows with differing bytes
+func (m *Model) jumpToHexChange(dir, maxVisible int) {
+	h := m.hex
+	rows := h.rows(m.hexBytesPerRow())
+	changedRow := func(r int) bool { return rowChanged(h.rowCells(r)) }
+
+	target := -1
+	if dir > 0 {
+		r := m.cursor
+		for r < rows && changedRow(r) {
+			r++
+		}
+		for ; r < rows; r++ {
+			if changedRow(r) {
+				target = r
+				break
+			}
+		}
+	} else {
+		r := m.cursor - 1
+		for r >= 0 && !changedRow(r) {
+			r--
+		}
+		for r > 0 && changedRow(r-1) {
+			r--
+		}
+		target = r
+	}
+	if target >= 0 {
+		m.moveCursorToLine(target, maxVisible)
+	}
+}
+
+// renderHexView renders the hex comparison of a binary pair
+func (m *Model) renderHexView() string {
+	h := m.hex
+	var b strings.Builder
+
+	indicator := " [Hex]"
+	if h.diff.Approximate {
+		indicator += " [approximate]"
+	}
+	if h.truncated {
+		indicator += fmt.Sprintf(" [first %s]", formatFileSize(maxHexBytes))
+	}
+	header := fmt.Sprintf("%s vs %s%s", m.currentDiff.LeftFile, m.currentDiff.RightFile, indicator)
+	b.WriteString(headerStyle.Width(m.windowWidth).Render(header))
+	b.WriteString("\n\n")
+
+	stats := fmt.Sprintf("Size: %s vs %s • SHA-256: %s vs %s",
+		formatFileSize(h.leftSize), formatFileSize(h.rightSize), shortHash(h.leftHash), shortHash(h.rightHash))
+	switch {
+	case h.leftHash == h.rightHash:
+		stats += " • identical"
+	case h.changed > 0:
+		stats += fmt.Sprintf(" • %d bytes differ", h.changed)
+	}
+	b.WriteString(helpStyle.Width(m.windowWidth).Render(stats))
+	b.WriteString("\n\n")
+
+	b.WriteString(m.renderHexContent())
+	b.WriteString(m.renderStatus())
+	b.WriteString("\n")
+
+	var helpText string
+	if m.windowWidth > 80 {
+		helpText = "↑↓/j/k: Navigate • g/G: Top/Bottom • [/]: Prev/Next difference • n/p: Next/Prev file • Esc: Back • ?: Help • Q: Quit"
+	} else {
+		helpText = "↑↓:Nav [/]:Diffs n/p:Files Esc:Back ?:Help Q:Quit"
+	}
+	b.WriteString(helpStyle.Width(m.windowWidth).Render(helpText))
+	return b.String()
+}
+
+func shortHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	return hash[:12]
+}
+
+// renderHexContent renders the visible rows: offset, hex bytes and ASCII for
+// each side, with differing bytes highlighted and gaps where one side has
+// bytes the other lacks
+func (m *Model) renderHexContent() string {
+	h := m.hex
+	perRow := m.hexBytesPerRow()
+	rows := h.rows(perRow)
+	if rows == 0 {
+		return "Both files are empty\n"
+	}
+
+	maxVisible := m.windowHeight - 10
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	end := min(m.scrollOffset+maxVisible, rows)
+
+	var b strings.Builder
+	for r := m.scrollOffset; r < end; r++ {
+		cells := h.rowCells(r)
+		prefix := "  "
+		if r == m.cursor {
+			prefix = "▶ "
+		}
+		b.WriteString(prefix)
+		b.WriteString(renderHexSide(cells, perRow, h.leftOffs[r], true))
+		b.WriteString(" │ ")
+		b.WriteString(renderHexSide(cells, perRow, h.rightOffs[r], false))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderHexSide renders one side of a row as "offset: hex  ascii"
+func renderHexSide(cells []differ.ByteCell, perRow int, offset int64, left bool) string {
+	changed := unsized(insertLineStyle)
+	if left {
+		changed = unsized(deleteLineStyle)
+	}
+	same := unsized(equalLineStyle)
+
+	var hexCol, ascii strings.Builder
+	for _, c := range cells {
+		v := c.Right
+		if left {
+			v = c.Left
+		}
+		style := same
+		if c.Changed() {
+			style = changed
+		}
+		if v < 0 {
+			hexCol.WriteString(style.Render("--") + " ")
+			ascii.WriteString(style.Render(" "))
+			continue
+		}
+		hexCol.WriteString(style.Render(fmt.Sprintf("%02x", v)) + " ")
+		ch := "."
+		if v >= 0x20 && v < 0x7F {
+			ch = string(rune(v))
+		}
+		ascii.WriteString(style.Render(ch))
+	}
+	pad := perRow - len(cells)
+	return fmt.Sprintf("%08x: %s%s %s%s", offset, hexCol.String(), strings.Repeat("   ", pad), ascii.String(), strings.Repeat(" ", pad))
+}
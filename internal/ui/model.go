@@ -1,11 +1,16 @@
 package ui
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"golang-fileCmp/internal/differ"
 	"golang-fileCmp/internal/file"
@@ -55,11 +60,41 @@ type Model struct {
 	// Diff view
 	currentDiff   *differ.FileDiff
 	sbsRows       []differ.SideBySideRow // precomputed side-by-side rows (cached from currentDiff)
+	hunks         []differ.Hunk          // change groups in currentDiff, for hunk navigation
+	lineStats     differ.Stats           // counts of currentDiff's lines and hunks, for the header
+	contextLines  int                    // equal lines around each hunk and in exported patches
 	scrollOffset  int
 	hScrollOffset int // horizontal scroll for side-by-side view
 	cursor        int
 	diffViewMode  DiffViewMode
 
+	// Paged diff of a file too large to load: where every page reached so
+	// far starts, which one is shown, and whether it is the last
+	pages    []differ.StreamPosition
+	page     int
+	lastPage bool
+
+	// Diff being computed in the background: cancelDiff stops it, and
+	// diffSeq numbers each run so a stale or cancelled result is dropped
+	cancelDiff context.CancelFunc
+	diffSeq    int
+
+	// Hex comparison of a binary pair, shown instead of currentDiff's lines
+	hex *hexView
+
+	// Structural comparison of a file whose format is understood, shown
+	// instead of the line diff unless preferLines is toggled on with v
+	structured  *differ.StructuredDiff
+	preferLines bool
+	structOpts  differ.StructuredOptions
+	comparators *differ.Registry
+
+	// Line stats badges of differing files in the file list, computed in
+	// the background under badgeCtx, which cancelBadges cancels
+	badges       map[*file.FileComparison]cachedBadge
+	badgeCtx     context.Context
+	cancelBadges context.CancelFunc
+
 	// Merge view
 	changeSelection *merge.ChangeSelection
 	mergeTarget     string // "left" or "right"
@@ -80,6 +115,7 @@ type Model struct {
 	focusLeft   bool
 	showingHelp bool
 	errorMsg    string
+	statusMsg   string // result of the last background action, cleared on the next key
 
 	// Path suggestions
 	leftSuggestions  []string
@@ -129,6 +165,39 @@ var (
 			Background(lipgloss.Color("#FF0000")).
 			Bold(true)
 
+	// Modified lines: the unchanged part of the line is muted so the
+	// intra-line changes keep the full insert/delete colours
+	modifiedDeleteStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFFFFF")).
+				Background(lipgloss.Color("#5F0000"))
+
+	modifiedInsertStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFFFFF")).
+				Background(lipgloss.Color("#00005F"))
+
+	// Moved blocks: where the text was removed from and where it went
+	movedFromStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#5F005F"))
+
+	movedToStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFFFF")).
+			Background(lipgloss.Color("#005F5F"))
+
+	// Insertions and deletions the active options treat as insignificant
+	ignoredLineStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#666666")).
+				Italic(true)
+
+	// Marker for equal lines that only matched under a relaxed comparison
+	equalizedMarkerStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#D7AF00")).
+				Bold(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#00D700")).
+			Bold(true)
+
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFFFF")).
 			Background(lipgloss.Color("#FF0000")).
@@ -186,12 +255,24 @@ var (
 			Padding(1)
 )
 
+// DefaultTimeBudget keeps an interactive diff responsive on pathological
+// input, at the cost of an approximate diff
+const DefaultTimeBudget = 2 * time.Second
+
+// DefaultDiffOptions returns the options the model diffs with unless
+// SetDiffOptions replaces them: differ.DefaultOptions with DefaultTimeBudget
+func DefaultDiffOptions() differ.Options {
+	opts := differ.DefaultOptions()
+	opts.TimeBudget = DefaultTimeBudget
+	return opts
+}
+
 // New creates a new model
 func New() *Model {
 	return &Model{
 		viewMode:        ViewModeFileSelect,
 		fileManager:     file.New(),
-		differ:          differ.New(),
+		differ:          differ.NewWithOptions(DefaultDiffOptions()),
 		merger:          merge.New(),
 		focusLeft:       true,
 		commonFiles:     make(map[string][2]*file.FileInfo),
@@ -204,6 +285,9 @@ func New() *Model {
 		copySelection: make(map[string]bool),
 		copyTarget:    "to-right",
 		diffViewMode:  DiffViewUnified,
+		contextLines:  differ.DefaultContext,
+		structOpts:    differ.DefaultStructuredOptions(),
+		comparators:   differ.NewDefaultRegistry(differ.DefaultStructuredOptions()),
 	}
 }
 
@@ -212,8 +296,14 @@ func (m *Model) Init() tea.Cmd {
 	return nil
 }
 
-// Update handles messages and updates the model
+// Update handles messages and updates the model, then starts computing
+// any file list badges the file list is now missing
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, m.requestBadges())
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.windowWidth = msg.Width
@@ -221,7 +311,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		m.statusMsg = ""
 		return m.handleKeyPress(msg)
+
+	case string:
+		// Background commands such as saves report their outcome as text
+		m.statusMsg = msg
+		return m, nil
+
+	case diffLoadedMsg:
+		m.diffLoaded(msg)
+		return m, nil
+
+	case badgeMsg:
+		m.badgeLoaded(msg)
+		return m, nil
 	}
 
 	return m, nil
@@ -324,8 +428,9 @@ func (m *Model) handleFileSelectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			// Load diff for the currently selected file
 			if m.selectedFile != "" {
-				m.loadDiff()
+				cmd := m.loadDiff()
 				m.viewMode = ViewModeDiff
+				return m, cmd
 			}
 		}
 		return m, nil
@@ -440,6 +545,16 @@ func (m *Model) handleDiffKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 
 	case "esc":
+		if m.cancelDiff != nil {
+			// Stop the running diff; keep showing the previous one if any
+			m.cancelDiff()
+			m.cancelDiff = nil
+			m.diffSeq++
+			m.statusMsg = "Diff cancelled"
+			if m.currentDiff != nil {
+				return m, nil
+			}
+		}
 		m.viewMode = ViewModeFileSelect
 		return m, nil
 
@@ -464,13 +579,18 @@ func (m *Model) handleDiffKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "left", "h":
-		if m.diffViewMode == DiffViewSideBySide && m.hScrollOffset > 0 {
+		if (m.diffViewMode == DiffViewSideBySide || m.showTable()) && m.hScrollOffset > 0 {
 			m.hScrollOffset--
 		}
 		return m, nil
 
 	case "right", "l":
-		if m.diffViewMode == DiffViewSideBySide {
+		if m.showTable() {
+			// The table view scrolls by whole columns
+			if m.hScrollOffset < len(m.structuredTable().Columns)-1 {
+				m.hScrollOffset++
+			}
+		} else if m.diffViewMode == DiffViewSideBySide {
 			m.hScrollOffset++
 		}
 		return m, nil
@@ -489,15 +609,29 @@ func (m *Model) handleDiffKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "]":
+		m.jumpToHunk(1, m.windowHeight-10)
+		return m, nil
+
+	case "[":
+		m.jumpToHunk(-1, m.windowHeight-10)
+		return m, nil
+
+	case "}":
+		m.turnPage(1)
+		return m, nil
+
+	case "{":
+		m.turnPage(-1)
+		return m, nil
+
 	case "n":
 		m.selectNextFile()
-		m.loadDiff()
-		return m, nil
+		return m, m.loadDiff()
 
 	case "p":
 		m.selectPreviousFile()
-		m.loadDiff()
-		return m, nil
+		return m, m.loadDiff()
 
 	case "?":
 		m.viewMode = ViewModeHelp
@@ -515,8 +649,86 @@ func (m *Model) handleDiffKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.hScrollOffset = 0
 		return m, nil
 
+	case "a":
+		// Cycle the diff algorithm and recompute the current diff
+		m.differ.Algorithm = m.differ.Algorithm.Next()
+		return m, m.reloadDiff()
+
+	case "w":
+		// Cycle intra-line highlighting through words, characters and tokens
+		m.differ.Granularity = m.differ.Granularity.Next()
+		return m, m.reloadDiff()
+
+	case "i":
+		// Cycle whitespace handling: exact → trailing → amount → all
+		m.differ.Whitespace = m.differ.Whitespace.Next()
+		return m, m.reloadDiff()
+
+	case "C":
+		// Toggle whether letter case takes part in the comparison
+		m.differ.FoldCase = !m.differ.FoldCase
+		return m, m.reloadDiff()
+
+	case "u":
+		// Cycle Unicode normalization: none → NFC → NFKC
+		m.differ.Normalization = m.differ.Normalization.Next()
+		return m, m.reloadDiff()
+
+	case "b":
+		// Toggle whether added/removed blank lines count as changes
+		m.differ.IgnoreBlankLines = !m.differ.IgnoreBlankLines
+		return m, m.reloadDiff()
+
+	case "r":
+		// Toggle whether line endings take part in the comparison
+		m.differ.IgnoreEOL = !m.differ.IgnoreEOL
+		return m, m.reloadDiff()
+
+	case "v":
+		// Switch between the structural summary and the line diff
+		m.toggleStructured()
+		return m, nil
+
+	case "enter":
+		// Show the selected structural change in the line diff
+		if m.showStructured() {
+			m.openStructuredChange(m.windowHeight - 10)
+		}
+		return m, nil
+
+	case "f":
+		// Follow a moved block to its other end
+		m.followMove(m.windowHeight - 10)
+		return m, nil
+
+	case "e":
+		// Export the current file's diff as a unified patch
+		if m.pages != nil {
+			m.errorMsg = "Cannot export: file is too large to load"
+			return m, nil
+		}
+		if m.hex != nil {
+			m.errorMsg = "Cannot export a patch of binary files"
+			return m, nil
+		}
+		if m.showStructured() {
+			return m, m.exportChanges()
+		}
+		if m.currentDiff != nil {
+			return m, m.exportPatch()
+		}
+		return m, nil
+
 	case "m":
 		// Enter merge mode if we have a diff loaded
+		if m.pages != nil {
+			m.errorMsg = "Cannot merge: file is too large to load"
+			return m, nil
+		}
+		if m.hex != nil {
+			m.errorMsg = "Cannot merge binary files"
+			return m, nil
+		}
 		if m.currentDiff != nil {
 			// Check if this is a valid file for merging
 			if m.selectedFile != "" {
@@ -592,6 +804,24 @@ func (m *Model) handleMergeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "h":
+		// Toggle every change in the hunk under the cursor
+		if m.currentDiff != nil {
+			if k := differ.HunkAt(m.hunks, m.cursor); k >= 0 {
+				m.changeSelection.ToggleHunk(m.currentDiff, m.hunks[k])
+				m.updateMergePreview()
+			}
+		}
+		return m, nil
+
+	case "]":
+		m.jumpToHunk(1, m.windowHeight-15)
+		return m, nil
+
+	case "[":
+		m.jumpToHunk(-1, m.windowHeight-15)
+		return m, nil
+
 	case "a":
 		// Select all changes
 		m.changeSelection.SelectAll(m.currentDiff)
@@ -749,6 +979,8 @@ func (m *Model) updateCommonFiles() {
 		m.commonFiles = file.FindCommonFiles(m.leftFile, m.rightFile)
 		m.allFiles = file.FindAllFiles(m.leftFile, m.rightFile)
 		m.fileListScroll = 0 // Reset scroll when files change
+		m.stopBadges()
+		m.badges = nil
 
 		// Select first file by default if none selected
 		if len(m.allFiles) > 0 && m.selectedFile == "" {
@@ -873,6 +1105,8 @@ func (m *Model) LoadGitComparison(leftRef, rightRef string) error {
 
 	m.allFiles = make(map[string]*file.FileComparison)
 	m.commonFiles = make(map[string][2]*file.FileInfo)
+	m.stopBadges()
+	m.badges = nil
 	m.selectedFile = ""
 
 	for _, fs := range statuses {
@@ -889,12 +1123,7 @@ func (m *Model) LoadGitComparison(leftRef, rightRef string) error {
 			if err != nil {
 				continue
 			}
-			comparison.RightFile = &file.FileInfo{
-				Path:    filepath.Join(root, fs.Path),
-				Name:    filepath.Base(fs.Path),
-				Content: content,
-				Size:    int64(len(content)),
-			}
+			comparison.RightFile = file.NewFileInfo(filepath.Join(root, fs.Path), filepath.Base(fs.Path), content)
 			comparison.Source = file.SourceRight
 
 		case 'D': // Deleted — only exists in leftRef
@@ -902,12 +1131,7 @@ func (m *Model) LoadGitComparison(leftRef, rightRef string) error {
 			if err != nil {
 				continue
 			}
-			comparison.LeftFile = &file.FileInfo{
-				Path:    fmt.Sprintf("git:%s:%s", leftRef, fs.Path),
-				Name:    filepath.Base(fs.Path),
-				Content: content,
-				Size:    int64(len(content)),
-			}
+			comparison.LeftFile = file.NewFileInfo(fmt.Sprintf("git:%s:%s", leftRef, fs.Path), filepath.Base(fs.Path), content)
 			comparison.Source = file.SourceLeft
 
 		default: // Modified — exists in both
@@ -924,18 +1148,8 @@ func (m *Model) LoadGitComparison(leftRef, rightRef string) error {
 			if err != nil {
 				continue
 			}
-			lf := &file.FileInfo{
-				Path:    fmt.Sprintf("git:%s:%s", leftRef, fs.Path),
-				Name:    filepath.Base(fs.Path),
-				Content: leftContent,
-				Size:    int64(len(leftContent)),
-			}
-			rf := &file.FileInfo{
-				Path:    filepath.Join(root, fs.Path),
-				Name:    filepath.Base(fs.Path),
-				Content: rightContent,
-				Size:    int64(len(rightContent)),
-			}
+			lf := file.NewFileInfo(fmt.Sprintf("git:%s:%s", leftRef, fs.Path), filepath.Base(fs.Path), leftContent)
+			rf := file.NewFileInfo(filepath.Join(root, fs.Path), filepath.Base(fs.Path), rightContent)
 			comparison.LeftFile = lf
 			comparison.RightFile = rf
 			comparison.Source = file.SourceBoth
@@ -956,16 +1170,36 @@ func (m *Model) LoadGitComparison(leftRef, rightRef string) error {
 	return nil
 }
 
-func (m *Model) loadDiff() {
+// loadDiff starts comparing the selected file. Files small enough to load
+// are diffed in the background by the returned command, which Esc cancels;
+// large files are paged from disk straight away.
+func (m *Model) loadDiff() tea.Cmd {
+	m.stopDiff()
+	m.stopBadges()
+	m.currentDiff, m.sbsRows, m.hunks = nil, nil, nil
+
 	if m.selectedFile == "" {
 		m.errorMsg = "No file selected for comparison"
-		return
+		return nil
 	}
 
 	fileComparison, exists := m.allFiles[m.selectedFile]
 	if !exists {
 		m.errorMsg = fmt.Sprintf("Selected file '%s' no longer exists", m.selectedFile)
-		return
+		return nil
+	}
+
+	m.pages = nil
+	if fileComparison.IsBinary() {
+		return m.startHexDiff(fileComparison)
+	}
+
+	if (fileComparison.LeftFile != nil && fileComparison.LeftFile.Large) ||
+		(fileComparison.RightFile != nil && fileComparison.RightFile.Large) {
+		m.pages = []differ.StreamPosition{{LeftLine: 1, RightLine: 1}}
+		m.page = 0
+		m.showPage()
+		return nil
 	}
 
 	var leftContent, rightContent, leftPath, rightPath string
@@ -992,21 +1226,217 @@ func (m *Model) loadDiff() {
 		rightPath = rightFile.Path
 	}
 
-	diff := m.differ.CompareStrings(
-		leftPath,
-		rightPath,
-		leftContent,
-		rightContent,
-	)
+	return m.startDiff(leftPath, rightPath, leftContent, rightContent, false)
+}
+
+// diffLoadedMsg carries the result of a background diff
+type diffLoadedMsg struct {
+	seq           int
+	diff          *differ.FileDiff
+	hex           *hexView // set for binary files; diff then only names them
+	structured    *differ.StructuredDiff
+	structuredErr error // the structural comparison failed; the line diff stands
+	err           error
+	reload        bool // keep the cursor where it was, as after an option change
+}
+
+// startDiff returns a command that diffs the contents in the background
+// with a snapshot of the current options, so keys pressed meanwhile cannot
+// change them under it
+func (m *Model) startDiff(leftPath, rightPath, leftContent, rightContent string, reload bool) tea.Cmd {
+	ctx, seq, d := m.beginDiff()
+	name, comparators := m.selectedFile, m.comparators
+	return func() tea.Msg {
+		diff, err := d.CompareContext(ctx, leftPath, rightPath, leftContent, rightContent)
+		if err != nil {
+			return diffLoadedMsg{seq: seq, err: err}
+		}
+		structured, structuredErr := comparators.Compare(ctx, name, leftContent, rightContent)
+		if ctx.Err() != nil {
+			return diffLoadedMsg{seq: seq, err: ctx.Err()}
+		}
+		return diffLoadedMsg{seq: seq, diff: diff, structured: structured, structuredErr: structuredErr, reload: reload}
+	}
+}
+
+// beginDiff cancels any running diff and sets up the next one: its
+// context, its sequence number and a Differ with the current options
+func (m *Model) beginDiff() (context.Context, int, *differ.Differ) {
+	m.stopDiff()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelDiff = cancel
+	m.diffSeq++
+	return ctx, m.diffSeq, differ.NewWithOptions(m.differ.Options)
+}
+
+// stopDiff cancels the background diff, if one is running
+func (m *Model) stopDiff() {
+	if m.cancelDiff != nil {
+		m.cancelDiff()
+		m.cancelDiff = nil
+	}
+}
+
+// diffLoaded shows the result of the latest background diff
+func (m *Model) diffLoaded(msg diffLoadedMsg) {
+	if msg.seq != m.diffSeq {
+		return // superseded or cancelled
+	}
+	m.cancelDiff = nil
+	if msg.err != nil {
+		m.errorMsg = fmt.Sprintf("Diff failed: %v", msg.err)
+		return
+	}
+
+	cursor, scroll, hScroll := m.cursor, m.scrollOffset, m.hScrollOffset
+	m.setDiff(msg.diff)
+	m.hex = msg.hex
+	m.structured = msg.structured
+	if msg.reload {
+		m.restorePosition(cursor, scroll, hScroll)
+	}
+	if msg.diff.Approximate || (msg.hex != nil && msg.hex.diff.Approximate) {
+		m.statusMsg = "Diff took too long to refine; showing an approximate result"
+	}
+	if msg.structuredErr != nil {
+		m.statusMsg = fmt.Sprintf("Showing the line diff: %v", msg.structuredErr)
+	}
+}
 
+// setDiff shows diff from the top
+func (m *Model) setDiff(diff *differ.FileDiff) {
 	m.currentDiff = diff
+	m.hex = nil
+	m.structured = nil
 	m.sbsRows = differ.BuildSideBySideRows(diff.Lines)
+	m.hunks = diff.Hunks(m.contextLines)
+	m.lineStats = diff.Stats(m.hunks)
 	m.cursor = 0
 	m.scrollOffset = 0
 	m.hScrollOffset = 0
 	m.errorMsg = "" // Clear any previous errors
 }
 
+// showPage streams page m.page of a large file's diff from disk. Only the
+// page is held in memory; the start of the following page is recorded so
+// paging forward and back both resume from a known position.
+func (m *Model) showPage() {
+	fileComparison := m.allFiles[m.selectedFile]
+	left, leftPath, err := openPagedSide(fileComparison.LeftFile)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to open left file: %v", err)
+		return
+	}
+	defer left.Close()
+	right, rightPath, err := openPagedSide(fileComparison.RightFile)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to open right file: %v", err)
+		return
+	}
+	defer right.Close()
+
+	leftSize, _ := left.Seek(0, io.SeekEnd)
+	rightSize, _ := right.Seek(0, io.SeekEnd)
+
+	stream, err := m.differ.ResumeStream(leftPath, rightPath, left, right, m.pages[m.page], differ.DefaultStreamOptions())
+	if err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	page, err := stream.Next()
+	if errors.Is(err, io.EOF) {
+		page, err = &differ.FileDiff{LeftFile: leftPath, RightFile: rightPath}, nil
+	}
+	if err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+
+	next := stream.Position()
+	m.lastPage = next.LeftOffset >= leftSize && next.RightOffset >= rightSize
+	if !m.lastPage && m.page == len(m.pages)-1 {
+		m.pages = append(m.pages, next)
+	}
+	m.setDiff(page)
+}
+
+// openPagedSide opens one side of a paged diff; a missing file reads as empty
+func openPagedSide(fi *file.FileInfo) (io.ReadSeekCloser, string, error) {
+	if fi == nil {
+		r, err := (&file.FileInfo{}).OpenText()
+		return r, "<file not found>", err
+	}
+	r, err := fi.OpenText()
+	return r, fi.Path, err
+}
+
+// turnPage moves to the next (dir > 0) or previous page of a paged diff
+func (m *Model) turnPage(dir int) {
+	if m.pages == nil {
+		return
+	}
+	switch {
+	case dir > 0 && !m.lastPage:
+		m.page++
+	case dir < 0 && m.page > 0:
+		m.page--
+	default:
+		return
+	}
+	m.showPage()
+}
+
+// reloadDiff recomputes the current diff (e.g. after an option change)
+// while keeping the cursor and scroll position where possible.
+func (m *Model) reloadDiff() tea.Cmd {
+	if m.currentDiff == nil {
+		if m.cancelDiff != nil {
+			// Still computing: start over with the new options
+			return m.loadDiff()
+		}
+		return nil
+	}
+
+	if m.hex != nil {
+		return m.loadDiff()
+	}
+
+	cursor, scroll, hScroll := m.cursor, m.scrollOffset, m.hScrollOffset
+	if m.pages != nil {
+		// Later pages may start elsewhere under the new options
+		m.pages = m.pages[:m.page+1]
+		m.showPage()
+		m.restorePosition(cursor, scroll, hScroll)
+		return nil
+	}
+
+	fileComparison, exists := m.allFiles[m.selectedFile]
+	if !exists {
+		return nil
+	}
+	var leftContent, rightContent string
+	if fileComparison.LeftFile != nil {
+		leftContent = fileComparison.LeftFile.Content
+	}
+	if fileComparison.RightFile != nil {
+		rightContent = fileComparison.RightFile.Content
+	}
+	return m.startDiff(m.currentDiff.LeftFile, m.currentDiff.RightFile, leftContent, rightContent, true)
+}
+
+// restorePosition puts the cursor and scroll offsets back after the diff
+// was recomputed, clamped to its new length
+func (m *Model) restorePosition(cursor, scroll, hScroll int) {
+	maxLines := m.maxDiffLines()
+	if cursor >= maxLines {
+		cursor = max(0, maxLines-1)
+	}
+	if scroll > cursor {
+		scroll = cursor
+	}
+	m.cursor, m.scrollOffset, m.hScrollOffset = cursor, scroll, hScroll
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -1014,8 +1444,38 @@ func max(a, b int) int {
 	return b
 }
 
+// diffOptionsLabel summarises the active diff options for the header
+func (m *Model) diffOptionsLabel() string {
+	parts := []string{m.differ.Algorithm.String(), m.differ.Granularity.String()}
+	if m.differ.Whitespace != differ.WhitespaceExact {
+		parts = append(parts, "ws:"+m.differ.Whitespace.String())
+	}
+	if m.differ.FoldCase {
+		parts = append(parts, "fold-case")
+	}
+	if m.differ.Normalization != differ.NormalizationNone {
+		parts = append(parts, m.differ.Normalization.String())
+	}
+	if m.differ.IgnoreBlankLines {
+		parts = append(parts, "ignore-blank")
+	}
+	if m.differ.IgnoreEOL {
+		parts = append(parts, "ignore-eol")
+	}
+	if n := len(m.differ.IgnoreRules); n > 0 {
+		parts = append(parts, fmt.Sprintf("rules:%d", n))
+	}
+	return strings.Join(parts, " · ")
+}
+
 // maxDiffLines returns the total navigable lines for the current view mode.
 func (m *Model) maxDiffLines() int {
+	if m.hex != nil {
+		return m.hex.rows(m.hexBytesPerRow())
+	}
+	if m.showStructured() {
+		return m.structuredLen()
+	}
 	if m.diffViewMode == DiffViewSideBySide {
 		return len(m.sbsRows)
 	}
@@ -1025,6 +1485,147 @@ func (m *Model) maxDiffLines() int {
 	return len(m.currentDiff.Lines)
 }
 
+// cursorLine returns the index in currentDiff.Lines under the cursor
+func (m *Model) cursorLine() int {
+	if m.viewMode == ViewModeDiff && m.diffViewMode == DiffViewSideBySide {
+		if m.cursor >= len(m.sbsRows) {
+			return -1
+		}
+		row := m.sbsRows[m.cursor]
+		if row.LeftIndex >= 0 {
+			return row.LeftIndex
+		}
+		return row.RightIndex
+	}
+	return m.cursor
+}
+
+// moveCursorToLine places the cursor on the given index in
+// currentDiff.Lines and scrolls it into view
+func (m *Model) moveCursorToLine(index, maxVisible int) {
+	cursor := index
+	if m.viewMode == ViewModeDiff && m.diffViewMode == DiffViewSideBySide && m.hex == nil && !m.showStructured() {
+		for i, row := range m.sbsRows {
+			if row.LeftIndex >= index || row.RightIndex >= index {
+				cursor = i
+				break
+			}
+		}
+	}
+	m.cursor = cursor
+	if m.cursor < m.scrollOffset || m.cursor >= m.scrollOffset+maxVisible {
+		// Leave a little context above the change
+		m.scrollOffset = max(0, m.cursor-m.contextLines)
+	}
+}
+
+// jumpToHunk moves the cursor to the first change of the next (dir > 0)
+// or previous (dir < 0) hunk
+func (m *Model) jumpToHunk(dir, maxVisible int) {
+	if m.hex != nil {
+		m.jumpToHexChange(dir, maxVisible)
+		return
+	}
+	if m.showStructured() {
+		// Every row of the summary is a change
+		if target := m.cursor + dir; target >= 0 && target < m.structuredLen() {
+			m.moveCursorToLine(target, maxVisible)
+		}
+		return
+	}
+	current := m.cursorLine()
+	target := -1
+	for _, h := range m.hunks {
+		first := firstChange(h)
+		if dir > 0 && first > current {
+			target = first
+			break
+		}
+		if dir < 0 && first < current {
+			target = first
+		}
+	}
+	if target >= 0 {
+		m.moveCursorToLine(target, maxVisible)
+	}
+}
+
+// followMove jumps from a moved block's source to its destination or back
+func (m *Model) followMove(maxVisible int) {
+	i := m.cursorLine()
+	if m.currentDiff == nil || i < 0 || i >= len(m.currentDiff.Lines) {
+		return
+	}
+	mv, ok := m.currentDiff.MoveAt(i)
+	if !ok {
+		return
+	}
+	if i >= mv.FromStart && i < mv.FromEnd {
+		m.moveCursorToLine(mv.ToStart, maxVisible)
+	} else {
+		m.moveCursorToLine(mv.FromStart, maxVisible)
+	}
+}
+
+// firstChange returns the index in FileDiff.Lines of the hunk's first change
+func firstChange(h differ.Hunk) int {
+	for i, line := range h.Lines {
+		if line.Type != differ.DiffEqual && !line.Ignored {
+			return h.Start + i
+		}
+	}
+	return h.Start
+}
+
+// SetContextLines sets the context used for hunks and exported patches
+func (m *Model) SetContextLines(n int) {
+	m.contextLines = n
+}
+
+// SetJSONOptions sets how JSON files are compared structurally
+func (m *Model) SetJSONOptions(opts differ.JSONOptions) {
+	m.structOpts.JSON = opts
+	m.registerBuiltins()
+}
+
+// SetTableOptions sets how CSV and TSV rows are matched
+func (m *Model) SetTableOptions(opts differ.TableOptions) {
+	m.structOpts.Table = opts
+	m.registerBuiltins()
+}
+
+// SetKeyValueOptions sets how INI, .env and properties files are compared
+func (m *Model) SetKeyValueOptions(opts differ.KeyValueOptions) {
+	m.structOpts.KeyValue = opts
+	m.registerBuiltins()
+}
+
+// SetYAMLOptions sets how YAML files are compared structurally
+func (m *Model) SetYAMLOptions(opts differ.YAMLOptions) {
+	m.structOpts.YAML = opts
+	m.registerBuiltins()
+}
+
+// registerBuiltins replaces the built-in comparators with ones set up with
+// the current options
+func (m *Model) registerBuiltins() {
+	for _, c := range differ.BuiltinComparators(m.structOpts) {
+		m.comparators.Register(c)
+	}
+}
+
+// Comparators returns the registry that picks a structural comparator for
+// each file. Register comparators with it, or assign them to file patterns,
+// to have files compared by their own format.
+func (m *Model) Comparators() *differ.Registry {
+	return m.comparators
+}
+
+// SetDiffOptions replaces the options used for all subsequent diffs
+func (m *Model) SetDiffOptions(opts differ.Options) {
+	m.differ = differ.NewWithOptions(opts)
+}
+
 // SetLeftPath sets the left path and loads it
 func (m *Model) SetLeftPath(path string) {
 	m.inputLeft = path
@@ -1197,16 +1798,34 @@ func (m *Model) saveMergedFile() tea.Cmd {
 
 		var result *merge.MergeResult
 		var targetPath string
+		var target *file.FileInfo
+		fc := m.allFiles[m.selectedFile]
 
 		if m.mergeTarget == "left" {
 			result = m.merger.ApplyToLeft(m.currentDiff, m.changeSelection)
 			targetPath = m.currentDiff.LeftFile + ".merged"
+			if fc != nil {
+				target = fc.LeftFile
+			}
 		} else {
 			result = m.merger.ApplyToRight(m.currentDiff, m.changeSelection)
 			targetPath = m.currentDiff.RightFile + ".merged"
+			if fc != nil {
+				target = fc.RightFile
+			}
+		}
+
+		// Write the result back in the target's own encoding, BOM included
+		content := []byte(result.Content)
+		if target != nil && !target.Encoding.IsUTF8() {
+			encoded, err := target.Encoding.Encode(result.Content)
+			if err != nil {
+				return fmt.Sprintf("Error saving merged file: %s", err.Error())
+			}
+			content = encoded
 		}
 
-		err := os.WriteFile(targetPath, []byte(result.Content), 0644)
+		err := os.WriteFile(targetPath, content, 0644)
 		if err != nil {
 			return fmt.Sprintf("Error saving merged file: %s", err.Error())
 		}
@@ -1216,6 +1835,45 @@ func (m *Model) saveMergedFile() tea.Cmd {
 	}
 }
 
+// exportPatch writes the current diff as a unified patch in the working
+// directory, labelled a/ and b/ so it applies with git apply or patch -p1
+func (m *Model) exportPatch() tea.Cmd {
+	diff := *m.currentDiff
+	name := m.selectedFile
+	if name == "" || name == "." {
+		// Two single files were compared; label each by its own name
+		name = filepath.Base(diff.LeftFile)
+		diff.LeftFile, diff.RightFile = "a/"+name, "b/"+filepath.Base(diff.RightFile)
+	} else {
+		diff.LeftFile, diff.RightFile = "a/"+name, "b/"+name
+	}
+	if fc, ok := m.allFiles[m.selectedFile]; ok {
+		switch fc.Source {
+		case file.SourceLeft:
+			diff.RightFile = "/dev/null"
+		case file.SourceRight:
+			diff.LeftFile = "/dev/null"
+		}
+	}
+	context := m.contextLines
+
+	return func() tea.Msg {
+		var buf bytes.Buffer
+		if err := differ.WriteUnified(&buf, &diff, context); err != nil {
+			return fmt.Sprintf("Error writing patch: %s", err.Error())
+		}
+		if buf.Len() == 0 {
+			return "No changes to export"
+		}
+
+		targetPath := strings.ReplaceAll(name, string(filepath.Separator), "_") + ".patch"
+		if err := os.WriteFile(targetPath, buf.Bytes(), 0644); err != nil {
+			return fmt.Sprintf("Error writing patch: %s", err.Error())
+		}
+		return fmt.Sprintf("Wrote patch to %s", targetPath)
+	}
+}
+
 // hasUniqueFiles checks if there are any files that exist in only one directory
 func (m *Model) hasUniqueFiles() bool {
 	for _, fileComp := range m.allFiles {
@@ -1306,7 +1964,7 @@ func (m *Model) executeCopyOperation() tea.Cmd {
 			}
 
 			// Copy file
-			if err := os.WriteFile(dstPath, []byte(srcFile.Content), 0644); err != nil {
+			if err := srcFile.CopyTo(dstPath); err != nil {
 				errors = append(errors, fmt.Sprintf("Failed to copy %s: %v", relPath, err))
 				errorCount++
 				continue
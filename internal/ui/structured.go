@@ -0,0 +1,312 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"golang-fileCmp/internal/differ"
+)
+
+// showStructured reports whether the structural summary is shown rather
+// than the line diff
+func (m *Model) showStructured() bool {
+	return m.structured != nil && !m.preferLines
+}
+
+// showTable reports whether the table view of a CSV or TSV file is shown
+func (m *Model) showTable() bool {
+	return m.showStructured() && m.structuredTable() != nil
+}
+
+// structuredTable returns the row-level comparison of a CSV or TSV file,
+// or nil for other formats
+func (m *Model) structuredTable() *differ.TableDiff {
+	t, _ := m.structured.Detail.(*differ.TableDiff)
+	return t
+}
+
+// structuredLen returns the number of navigable rows in the structural
+// summary: table rows for CSV and TSV, changes otherwise
+func (m *Model) structuredLen() int {
+	if t := m.structuredTable(); t != nil {
+		return len(t.Rows)
+	}
+	return len(m.structured.Changes)
+}
+
+// toggleStructured switches between the structural summary and the line
+// diff of the current file, starting the new view from the top
+func (m *Model) toggleStructured() {
+	if m.structured == nil {
+		m.statusMsg = "No structured view for this file"
+		return
+	}
+	m.preferLines = !m.preferLines
+	m.cursor, m.scrollOffset, m.hScrollOffset = 0, 0, 0
+	if m.preferLines && m.structured.Masked {
+		m.statusMsg = "Secrets are masked in the structural summary only; the line diff shows them"
+	}
+}
+
+// openStructuredChange switches to the line diff with the cursor on the
+// lines of the selected change or table row
+func (m *Model) openStructuredChange(maxVisible int) {
+	if m.currentDiff == nil || m.cursor >= m.structuredLen() {
+		return
+	}
+	var left, right int
+	if t := m.structuredTable(); t != nil {
+		left, right = t.Rows[m.cursor].LeftLine, t.Rows[m.cursor].RightLine
+	} else {
+		left, right = m.structured.Changes[m.cursor].LeftLine, m.structured.Changes[m.cursor].RightLine
+	}
+	if left == 0 && right == 0 {
+		m.statusMsg = "No line to show for this change"
+		return
+	}
+
+	target := len(m.currentDiff.Lines) - 1
+	for i, line := range m.currentDiff.Lines {
+		if (left > 0 && line.LeftLineNum >= left) || (right > 0 && line.RightLineNum >= right) {
+			target = i
+			break
+		}
+	}
+	m.preferLines = true
+	m.scrollOffset, m.hScrollOffset = 0, 0
+	m.moveCursorToLine(max(target, 0), maxVisible)
+}
+
+// exportChanges returns a command that writes the structural summary of
+// the current file to <file>.changes.txt
+func (m *Model) exportChanges() tea.Cmd {
+	s := m.structured
+	name := m.selectedFile
+	leftFile, rightFile := "a/"+name, "b/"+name
+	if name == "" || name == "." {
+		name = filepath.Base(m.currentDiff.LeftFile)
+		leftFile, rightFile = name, filepath.Base(m.currentDiff.RightFile)
+	}
+
+	return func() tea.Msg {
+		if len(s.Changes) == 0 {
+			return "No changes to export"
+		}
+		var buf bytes.Buffer
+		if err := differ.WriteChanges(&buf, s, leftFile, rightFile); err != nil {
+			return fmt.Sprintf("Error writing changes: %s", err.Error())
+		}
+		targetPath := strings.ReplaceAll(name, string(filepath.Separator), "_") + ".changes.txt"
+		if err := os.WriteFile(targetPath, buf.Bytes(), 0644); err != nil {
+			return fmt.Sprintf("Error writing changes: %s", err.Error())
+		}
+		return fmt.Sprintf("Wrote %s changes to %s", s.Format, targetPath)
+	}
+}
+
+// renderStructuredView renders the changes found by a structural
+// comparator, one per row
+func (m *Model) renderStructuredView() string {
+	s := m.structured
+	var b strings.Builder
+
+	header := fmt.Sprintf("%s vs %s [%s]", m.currentDiff.LeftFile, m.currentDiff.RightFile, s.Format)
+	b.WriteString(headerStyle.Width(m.windowWidth).Render(header))
+	b.WriteString("\n\n")
+
+	b.WriteString(helpStyle.Width(m.windowWidth).Render(s.Summary()))
+	b.WriteString("\n\n")
+
+	table := m.structuredTable()
+	if table != nil {
+		b.WriteString(m.renderTableContent())
+	} else {
+		b.WriteString(m.renderStructuredContent())
+	}
+	b.WriteString(m.renderStatus())
+	b.WriteString("\n")
+
+	var helpText string
+	if m.windowWidth > 80 {
+		helpText = "↑↓/j/k: Navigate • Enter: Show lines • g/G: Top/Bottom • v: Line diff • e: Export • n/p: Next/Prev file • m: Merge • Esc: Back • ?: Help • Q: Quit"
+		if table != nil {
+			helpText = "↑↓/j/k: Navigate • h/l: Scroll columns • Enter: Show lines • g/G: Top/Bottom • v: Line diff • e: Export • n/p: Next/Prev file • m: Merge • Esc: Back • ?: Help • Q: Quit"
+		}
+	} else {
+		helpText = "↑↓:Nav Enter:Show v:Lines n/p:Files m:Merge Esc:Back ?:Help Q:Quit"
+	}
+	b.WriteString(helpStyle.Width(m.windowWidth).Render(helpText))
+	return b.String()
+}
+
+func (m *Model) renderStructuredContent() string {
+	changes := m.structured.Changes
+	if len(changes) == 0 {
+		return fmt.Sprintf("No differences found: the %s documents are equivalent\n", m.structured.Format)
+	}
+
+	maxVisible := m.windowHeight - 10
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	end := min(m.scrollOffset+maxVisible, len(changes))
+	maxContentWidth := max(m.windowWidth-6, 20)
+
+	var b strings.Builder
+	for i := m.scrollOffset; i < end; i++ {
+		c := changes[i]
+		prefix := "  "
+		if i == m.cursor {
+			prefix = "▶ "
+		}
+		content := c.String()
+		if len(content) > maxContentWidth {
+			content = content[:maxContentWidth-3] + "..."
+		}
+		text := fmt.Sprintf("%s%s %s", prefix, c.Kind.Symbol(), content)
+
+		style := modifiedInsertStyle
+		switch c.Kind {
+		case differ.ChangeAdded:
+			style = insertLineStyle
+		case differ.ChangeRemoved:
+			style = deleteLineStyle
+		}
+		b.WriteString(style.Width(m.windowWidth - 2).Render(text))
+		b.WriteString("\n")
+	}
+
+	if len(changes) > maxVisible {
+		b.WriteString(helpStyle.Width(m.windowWidth).Render(fmt.Sprintf("Showing %d-%d of %d changes", m.scrollOffset+1, end, len(changes))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// maxTableCellWidth caps a column of the table view; longer values are cut
+const maxTableCellWidth = 24
+
+// tableCell returns what a table view cell shows: the value, or
+// "old → new" where a modified row's cell changed
+func tableCell(row differ.TableRow, col differ.TableColumn, i int) string {
+	switch {
+	case row.Kind == differ.ChangeRemoved:
+		return row.OldCells[i]
+	case row.CellChanged(col, i):
+		return row.OldCells[i] + " → " + row.Cells[i]
+	}
+	return row.Cells[i]
+}
+
+// fitCell pads or cuts s to exactly width columns
+func fitCell(s string, width int) string {
+	if w := displayWidth(s); w <= width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && displayWidth(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return fitCell(string(runes)+"…", width)
+}
+
+// renderTableContent renders the changed rows of a CSV or TSV diff as an
+// aligned table starting at column hScrollOffset, with changed cells and
+// column headers highlighted
+func (m *Model) renderTableContent() string {
+	t := m.structuredTable()
+	if len(t.Rows) == 0 && len(m.structured.Changes) == 0 {
+		return fmt.Sprintf("No differences found: the %s tables hold the same rows\n", m.structured.Format)
+	}
+
+	firstCol := min(m.hScrollOffset, max(len(t.Columns)-1, 0))
+	widths := make([]int, len(t.Columns))
+	for i, col := range t.Columns {
+		name := col.Name
+		if col.OldName != "" {
+			name = col.OldName + " → " + col.Name
+		}
+		widths[i] = displayWidth(name)
+		for _, row := range t.Rows {
+			widths[i] = max(widths[i], displayWidth(tableCell(row, col, i)))
+		}
+		widths[i] = min(widths[i], maxTableCellWidth)
+	}
+
+	// Columns that fit the window from firstCol on
+	lastCol := firstCol
+	used := 4 // cursor and kind markers
+	for lastCol < len(t.Columns) && (lastCol == firstCol || used+widths[lastCol]+3 <= m.windowWidth-2) {
+		used += widths[lastCol] + 3
+		lastCol++
+	}
+	sep := unsized(equalLineStyle).Render(" │ ")
+
+	var b strings.Builder
+	b.WriteString("    ")
+	for i := firstCol; i < lastCol; i++ {
+		col := t.Columns[i]
+		name, style := col.Name, unsized(headerStyle).Padding(0)
+		if kind, ok := col.Kind(); ok {
+			switch kind {
+			case differ.ChangeAdded:
+				style = unsized(insertLineStyle)
+			case differ.ChangeRemoved:
+				style = unsized(deleteLineStyle)
+			default:
+				name, style = col.OldName+" → "+col.Name, unsized(modifiedInsertStyle)
+			}
+		}
+		if i > firstCol {
+			b.WriteString(sep)
+		}
+		b.WriteString(style.Render(fitCell(name, widths[i])))
+	}
+	b.WriteString("\n")
+
+	maxVisible := max(m.windowHeight-11, 5) // one line less for the column headers
+	end := min(m.scrollOffset+maxVisible, len(t.Rows))
+	for r := m.scrollOffset; r < end; r++ {
+		row := t.Rows[r]
+		prefix := "  "
+		if r == m.cursor {
+			prefix = "▶ "
+		}
+		rowStyle := unsized(equalLineStyle)
+		switch row.Kind {
+		case differ.ChangeAdded:
+			rowStyle = unsized(insertLineStyle)
+		case differ.ChangeRemoved:
+			rowStyle = unsized(deleteLineStyle)
+		}
+
+		b.WriteString(prefix + row.Kind.Symbol() + " ")
+		for i := firstCol; i < lastCol; i++ {
+			col := t.Columns[i]
+			style := rowStyle
+			if row.CellChanged(col, i) {
+				style = unsized(insertLineStyle)
+			}
+			if i > firstCol {
+				b.WriteString(sep)
+			}
+			b.WriteString(style.Render(fitCell(tableCell(row, col, i), widths[i])))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(t.Rows) > maxVisible {
+		b.WriteString(helpStyle.Width(m.windowWidth).Render(fmt.Sprintf("Showing %d-%d of %d changed rows", m.scrollOffset+1, end, len(t.Rows))))
+		b.WriteString("\n")
+	}
+	if lastCol-firstCol < len(t.Columns) {
+		b.WriteString(helpStyle.Width(m.windowWidth).Render(fmt.Sprintf("Columns %d-%d of %d (h/l to scroll)", firstCol+1, lastCol, len(t.Columns))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -208,19 +208,9 @@ func (m *Model) renderFileSelectView() string {
 	return b.String()
 }
 
-// renderFileList renders the list of all files (common and unique)
-func (m *Model) renderFileList() string {
-	if len(m.allFiles) == 0 {
-		return ""
-	}
-
-	// Define styles for file status indicators
-	identicalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")).Bold(true) // Green
-	differentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Bold(true) // Red
-	leftOnlyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#0066FF")).Bold(true)  // Blue
-	rightOnlyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6600")).Bold(true) // Orange
-	sizeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))                 // Gray
-
+// fileListWindow returns the file list's entries, sorted and filtered, the
+// range of them shown, and the number of rows available to show them
+func (m *Model) fileListWindow() (allFiles []string, startIndex, endIndex, availableHeight int) {
 	// Calculate available space for file list
 	usedHeight := 15 // Approximate height used by title, inputs, status, help
 	if m.showSuggestions {
@@ -233,13 +223,13 @@ func (m *Model) renderFileList() string {
 		usedHeight += 3 // Space for error message
 	}
 
-	availableHeight := m.windowHeight - usedHeight
+	availableHeight = m.windowHeight - usedHeight
 	if availableHeight < 5 {
 		availableHeight = 5
 	}
 
 	// Get all files, sorted, then filtered
-	allFiles := make([]string, 0, len(m.allFiles))
+	allFiles = make([]string, 0, len(m.allFiles))
 	for relPath := range m.allFiles {
 		allFiles = append(allFiles, relPath)
 	}
@@ -266,7 +256,6 @@ func (m *Model) renderFileList() string {
 	}
 
 	// Calculate scroll offset to keep selected item visible
-	startIndex := 0
 	if len(allFiles) > availableHeight {
 		startIndex = selectedIndex - availableHeight/2
 		if startIndex < 0 {
@@ -277,11 +266,29 @@ func (m *Model) renderFileList() string {
 		}
 	}
 
-	endIndex := startIndex + availableHeight
+	endIndex = startIndex + availableHeight
 	if endIndex > len(allFiles) {
 		endIndex = len(allFiles)
 	}
 
+	return allFiles, startIndex, endIndex, availableHeight
+}
+
+// renderFileList renders the list of all files (common and unique)
+func (m *Model) renderFileList() string {
+	if len(m.allFiles) == 0 {
+		return ""
+	}
+
+	// Define styles for file status indicators
+	identicalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")).Bold(true) // Green
+	differentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Bold(true) // Red
+	leftOnlyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#0066FF")).Bold(true)  // Blue
+	rightOnlyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6600")).Bold(true) // Orange
+	sizeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))                 // Gray
+
+	allFiles, startIndex, endIndex, availableHeight := m.fileListWindow()
+
 	// Calculate max width for file display
 	maxFileWidth := m.windowWidth - 8 // Account for borders and padding
 
@@ -297,10 +304,14 @@ func (m *Model) renderFileList() string {
 			leftFile := fileComparison.LeftFile
 			rightFile := fileComparison.RightFile
 
-			// Check if files are identical
-			isIdentical := leftFile.Content == rightFile.Content
-			if isIdentical {
+			// Check if files are identical; large files are only read when compared
+			isIdentical, known := leftFile.SameContent(rightFile)
+			if !known {
+				statusIndicator = sizeStyle.Render("?")
+			} else if isIdentical {
 				statusIndicator = identicalStyle.Render("✓")
+			} else if leftFile.SameText(rightFile) {
+				statusIndicator = sizeStyle.Render("≈") // same text, different encoding
 			} else {
 				statusIndicator = differentStyle.Render("✗")
 			}
@@ -325,6 +336,15 @@ func (m *Model) renderFileList() string {
 			sizeInfo = sizeStyle.Render(fmt.Sprintf("(%s)", formatFileSize(rightFile.Size)))
 			sourceInfo = rightOnlyStyle.Render(" [RIGHT ONLY]")
 		}
+		if fileComparison.IsBinary() {
+			sourceInfo += sizeStyle.Render(" [binary]")
+		}
+		if badge := m.fileBadge(fileComparison); badge != "" {
+			sourceInfo += sizeStyle.Render(" " + badge)
+		}
+		if outline := m.fileOutline(fileComparison); outline != "" {
+			sourceInfo += sizeStyle.Render(" " + outline)
+		}
 
 		// Truncate filename if too long.
 		// Use lipgloss.Width to measure rendered strings — len() counts ANSI escape bytes too.
@@ -373,15 +393,58 @@ func formatFileSize(size int64) string {
 // renderDiffView renders the diff comparison view
 func (m *Model) renderDiffView() string {
 	if m.currentDiff == nil {
+		if m.cancelDiff != nil {
+			return "Computing diff… (Esc to cancel)"
+		}
 		return "No diff loaded"
 	}
+	if m.hex != nil {
+		return m.renderHexView()
+	}
+	if m.showStructured() {
+		return m.renderStructuredView()
+	}
 
 	var b strings.Builder
 
+	var viewModeIndicator string
+	if m.diffViewMode == DiffViewSideBySide {
+		viewModeIndicator = " [Side-by-Side]"
+	} else {
+		viewModeIndicator = " [Unified]"
+	}
+
+	viewModeIndicator += fmt.Sprintf(" [%s]", m.diffOptionsLabel())
+	if m.cancelDiff != nil {
+		viewModeIndicator += " [recomputing…]"
+	} else if m.currentDiff.Approximate {
+		viewModeIndicator += " [approximate]"
+	}
+	if d := m.currentDiff; d.EOLDiffers() {
+		viewModeIndicator += fmt.Sprintf(" [%s → %s]", eolLabel(d.LeftEOL, d.LeftNoFinalNewline), eolLabel(d.RightEOL, d.RightNoFinalNewline))
+	}
+	fc := m.allFiles[m.selectedFile]
+	if label := encodingLabel(fc); label != "" {
+		viewModeIndicator += " [" + label + "]"
+	}
+	if m.structured != nil {
+		viewModeIndicator += fmt.Sprintf(" [v: %s view]", m.structured.Format)
+	}
+	if m.pages != nil {
+		if m.lastPage {
+			viewModeIndicator += fmt.Sprintf(" [Page %d/%d]", m.page+1, len(m.pages))
+		} else {
+			viewModeIndicator += fmt.Sprintf(" [Page %d]", m.page+1)
+		}
+	}
+
 	// Header with file names - truncate if too long
 	leftFile := m.currentDiff.LeftFile
 	rightFile := m.currentDiff.RightFile
-	maxFileNameWidth := (m.windowWidth - 4) / 2 // Split width for both names
+	maxFileNameWidth := (m.windowWidth - 8 - lipgloss.Width(viewModeIndicator)) / 2 // Split width for both names
+	if maxFileNameWidth < 10 {
+		maxFileNameWidth = 10
+	}
 	if len(leftFile) > maxFileNameWidth {
 		leftFile = "..." + leftFile[len(leftFile)-maxFileNameWidth+3:]
 	}
@@ -389,24 +452,40 @@ func (m *Model) renderDiffView() string {
 		rightFile = "..." + rightFile[len(rightFile)-maxFileNameWidth+3:]
 	}
 
-	var viewModeIndicator string
-	if m.diffViewMode == DiffViewSideBySide {
-		viewModeIndicator = " [Side-by-Side]"
-	} else {
-		viewModeIndicator = " [Unified]"
-	}
-
 	header := fmt.Sprintf("%s vs %s%s", leftFile, rightFile, viewModeIndicator)
 	b.WriteString(headerStyle.Width(m.windowWidth).Render(header))
 	b.WriteString("\n\n")
 
 	// Stats
-	equal, inserted, deleted := m.currentDiff.GetStats()
+	lineStats := m.lineStats
 	var stats string
 	if m.windowWidth > 60 {
-		stats = fmt.Sprintf("Lines: %d equal, %d inserted (+), %d deleted (-)", equal, inserted, deleted)
+		stats = fmt.Sprintf("Lines: %d equal, %d inserted (+), %d deleted (-), %d modified • %s similar",
+			lineStats.Equal, lineStats.Inserted, lineStats.Deleted, lineStats.Modified, lineStats.SimilarityText())
+		if n := lineStats.WhitespaceOnly; n > 0 {
+			stats += fmt.Sprintf(" • %d whitespace-only", n)
+		}
+		if summary := equalizedSummary(m.currentDiff.Lines); summary != "" {
+			stats += " • " + summary
+		}
 	} else {
-		stats = fmt.Sprintf("%d equal, %d added, %d deleted", equal, inserted, deleted)
+		stats = fmt.Sprintf("%d equal, %d added, %d deleted", lineStats.Equal, lineStats.Inserted, lineStats.Deleted)
+	}
+	if n := len(m.currentDiff.Moves); n > 0 {
+		stats += fmt.Sprintf(" • %d moved", n)
+	}
+	if fc != nil && fc.Source == file.SourceBoth && fc.LeftFile.Encoding != fc.RightFile.Encoding && fc.LeftFile.SameText(fc.RightFile) {
+		stats += " • Only the encoding differs"
+	}
+	if len(m.hunks) > 0 {
+		if k := differ.HunkAt(m.hunks, m.cursorLine()); k >= 0 {
+			stats += fmt.Sprintf(" • Hunk %d/%d", k+1, len(m.hunks))
+		} else {
+			stats += fmt.Sprintf(" • %d hunks", len(m.hunks))
+		}
+		if m.windowWidth > 60 {
+			stats += fmt.Sprintf(", largest %d lines", lineStats.LargestHunk)
+		}
 	}
 	b.WriteString(helpStyle.Width(m.windowWidth).Render(stats))
 	b.WriteString("\n\n")
@@ -417,13 +496,14 @@ func (m *Model) renderDiffView() string {
 	} else {
 		b.WriteString(m.renderDiffContent())
 	}
+	b.WriteString(m.renderStatus())
 
 	// Navigation help - adapt to width and view mode
 	b.WriteString("\n")
 	var helpText string
 	if m.diffViewMode == DiffViewSideBySide {
 		if m.windowWidth > 80 {
-			helpText = "↑↓/j/k: Navigate • h/l: Left/Right • g/G: Top/Bottom • s: Switch view • n/p: Next/Prev file • m: Merge • Esc: Back • ?: Help • Q: Quit"
+			helpText = "↑↓/j/k: Navigate • h/l: Left/Right • g/G: Top/Bottom • s: Switch view • a: Algorithm • w: Word/Char/Token • i/C/u/b/r: Whitespace/Case/Unicode/EOL • [/]: Prev/Next hunk • {/}: Prev/Next page • f: Follow move • e: Export patch • n/p: Next/Prev file • m: Merge • Esc: Back • ?: Help • Q: Quit"
 		} else if m.windowWidth > 60 {
 			helpText = "↑↓/j/k: Navigate • h/l: Left/Right • s: Switch view • n/p: All files • m: Merge • Esc: Back • ?: Help • Q: Quit"
 		} else {
@@ -431,7 +511,7 @@ func (m *Model) renderDiffView() string {
 		}
 	} else {
 		if m.windowWidth > 80 {
-			helpText = "↑↓/j/k: Navigate • g/G: Top/Bottom • s: Switch view • n/p: Next/Prev file • m: Merge • Esc: Back • ?: Help • Q: Quit"
+			helpText = "↑↓/j/k: Navigate • g/G: Top/Bottom • s: Switch view • a: Algorithm • w: Word/Char/Token • i/C/u/b/r: Whitespace/Case/Unicode/EOL • [/]: Prev/Next hunk • {/}: Prev/Next page • f: Follow move • e: Export patch • n/p: Next/Prev file • m: Merge • Esc: Back • ?: Help • Q: Quit"
 		} else if m.windowWidth > 60 {
 			helpText = "↑↓/j/k: Navigate • g/G: Top/Bottom • s: Switch view • n/p: All files • m: Merge • Esc: Back • ?: Help • Q: Quit"
 		} else {
@@ -461,6 +541,7 @@ func (m *Model) renderDiffContent() string {
 		end = len(m.currentDiff.Lines)
 	}
 
+	showEOL := m.currentDiff.EOLDiffers()
 	for i := start; i < end; i++ {
 		line := m.currentDiff.Lines[i]
 		prefix := "  "
@@ -481,15 +562,40 @@ func (m *Model) renderDiffContent() string {
 			content = content[:maxContentWidth-3] + "..."
 		}
 
+		// Show line endings wherever they take part in a difference
+		marker := ""
+		if showEOL && line.Type != differ.DiffEqual {
+			marker = eolMarker(line.EOL)
+		} else if line.Equalized&differ.EqualizedEOL != 0 {
+			marker = eolMarker(line.EOL) + "→" + eolMarker(line.RightEOL)
+		}
+		content += marker
+
 		var renderedLine string
 		lineText := fmt.Sprintf("%s%s %s", prefix, lineNum, content)
 
-		switch line.Type {
-		case differ.DiffEqual:
+		switch {
+		case line.Type == differ.DiffEqual && line.Equalized != 0:
+			renderedLine = renderEqualizedLine(fmt.Sprintf("%s%s", prefix, lineNum), content, line.Equalized, m.windowWidth-2)
+		case line.Type == differ.DiffEqual:
 			renderedLine = equalLineStyle.Width(m.windowWidth - 2).Render(lineText)
-		case differ.DiffInsert:
+		case line.Ignored && line.Type == differ.DiffInsert:
+			renderedLine = ignoredLineStyle.Width(m.windowWidth - 2).Render(fmt.Sprintf("%s%s +%s", prefix, lineNum, content))
+		case line.Ignored && line.Type == differ.DiffDelete:
+			renderedLine = ignoredLineStyle.Width(m.windowWidth - 2).Render(fmt.Sprintf("%s%s -%s", prefix, lineNum, content))
+		case line.MoveID != 0 && line.Type == differ.DiffInsert:
+			renderedLine = movedToStyle.Width(m.windowWidth - 2).Render(fmt.Sprintf("%s%s >%s", prefix, lineNum, content))
+		case line.MoveID != 0 && line.Type == differ.DiffDelete:
+			renderedLine = movedFromStyle.Width(m.windowWidth - 2).Render(fmt.Sprintf("%s%s <%s", prefix, lineNum, content))
+		case line.Type == differ.DiffInsert && line.Spans != nil:
+			renderedLine = renderChangedLine(fmt.Sprintf("%s%s +", prefix, lineNum), line.Content+marker, line.Spans,
+				0, maxContentWidth, m.windowWidth-2, modifiedInsertStyle, insertLineStyle)
+		case line.Type == differ.DiffDelete && line.Spans != nil:
+			renderedLine = renderChangedLine(fmt.Sprintf("%s%s -", prefix, lineNum), line.Content+marker, line.Spans,
+				0, maxContentWidth, m.windowWidth-2, modifiedDeleteStyle, deleteLineStyle)
+		case line.Type == differ.DiffInsert:
 			renderedLine = insertLineStyle.Width(m.windowWidth - 2).Render(fmt.Sprintf("%s%s +%s", prefix, lineNum, content))
-		case differ.DiffDelete:
+		case line.Type == differ.DiffDelete:
 			renderedLine = deleteLineStyle.Width(m.windowWidth - 2).Render(fmt.Sprintf("%s%s -%s", prefix, lineNum, content))
 		}
 
@@ -541,11 +647,29 @@ Diff View Mode:
   g                Go to top of diff
   G                Go to bottom of diff
   s                Switch view mode (Unified ↔ Side-by-Side)
+  a                Cycle diff algorithm (Myers → Patience → Histogram)
+  w                Cycle intra-line highlighting (word → character → token)
+  i                Cycle whitespace handling (exact → trailing → amount → all)
+  C                Toggle ignoring letter case
+  u                Cycle Unicode normalization (none → NFC → NFKC)
+  b                Toggle ignoring added/removed blank lines
+  r                Toggle ignoring line endings (CRLF/LF, final newline)
+  ]/[              Jump to next/previous hunk
+                   (binary files open in a hex view; ]/[ jump between differences)
+  }/{              Next/previous page of a file too large to load
+  v                Switch between the structural summary (JSON, YAML,
+                   CSV/TSV table, INI/.env/properties keys, XML/HTML
+                   elements, Markdown sections, Go declarations) and the
+                   line diff
+  Enter            In the structural summary, show the change's lines
+  f                Jump between a moved block's source and destination
+  e                Export the current file's diff as a .patch file, or
+                   the structural summary as a .changes.txt file
   n                Next common file
   p                Previous common file
   m                Enter merge mode
   c                Enter copy mode (for unique files)
-  Esc              Return to file selection
+  Esc              Cancel a running diff / Return to file selection
   ?                Show this help screen
   Q/Ctrl+C         Quit application
 
@@ -555,6 +679,17 @@ Side-by-Side View Mode:
   g                Go to top of diff
   G                Go to bottom of diff
   s                Switch to Unified view mode
+  a                Cycle diff algorithm (Myers → Patience → Histogram)
+  w                Cycle intra-line highlighting (word → character → token)
+  i                Cycle whitespace handling (exact → trailing → amount → all)
+  C                Toggle ignoring letter case
+  u                Cycle Unicode normalization (none → NFC → NFKC)
+  b                Toggle ignoring added/removed blank lines
+  r                Toggle ignoring line endings (CRLF/LF, final newline)
+  ]/[              Jump to next/previous hunk
+  f                Jump between a moved block's source and destination
+  e                Export the current file's diff as a .patch file, or
+                   the structural summary as a .changes.txt file
   n                Next common file
   p                Previous common file
   m                Enter merge mode
@@ -566,6 +701,8 @@ Side-by-Side View Mode:
 Merge Mode:
   ↑/↓ or j/k       Navigate through diff lines
   Space/Enter      Toggle selection of current change
+  h                Toggle every change in the current hunk
+  ]/[              Jump to next/previous hunk
   t                Switch merge target (left/right)
   a                Select all changes
   n                Select no changes
@@ -595,8 +732,22 @@ Color Legend:
 	b.WriteString("\n")
 	b.WriteString(deleteLineStyle.Render("Red background: Deleted lines (-)"))
 	b.WriteString("\n")
+	b.WriteString(modifiedInsertStyle.Render("Dark background: ") + insertLineStyle.Render("changed words") + modifiedInsertStyle.Render(" within a modified line"))
+	b.WriteString("\n")
 	b.WriteString(equalLineStyle.Render("Gray text: Unchanged lines"))
 	b.WriteString("\n")
+	b.WriteString(equalizedMarkerStyle.Render("≈") + equalLineStyle.Render(" Equal only after ignoring differences (whitespace, case, Unicode form, line endings)"))
+	b.WriteString("\n")
+	b.WriteString(equalizedMarkerStyle.Render("≡") + equalLineStyle.Render(" Equal because of an ignore rule (--ignore-regex, --ignore-file)"))
+	b.WriteString("\n")
+	b.WriteString(movedFromStyle.Render("Purple background: Block moved away from here (<)"))
+	b.WriteString("\n")
+	b.WriteString(movedToStyle.Render("Teal background: Block moved here (>)"))
+	b.WriteString("\n")
+	b.WriteString(ignoredLineStyle.Render("Gray italic: Ignored changes (e.g. blank lines)"))
+	b.WriteString("\n")
+	b.WriteString(equalLineStyle.Render("␍␊ / ␊ / ⌀: CRLF, LF or no final newline, shown when line endings differ"))
+	b.WriteString("\n")
 	b.WriteString(selectedChangeStyle.Render("Yellow background: Selected changes (merge mode)"))
 	b.WriteString("\n")
 	b.WriteString(unselectedChangeStyle.Render("Strikethrough: Unselected changes (merge mode)"))
@@ -611,11 +762,16 @@ Color Legend:
 	differentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Bold(true)
 	leftOnlyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#0066FF")).Bold(true)
 	rightOnlyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6600")).Bold(true)
+	sizeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
 
 	b.WriteString(identicalStyle.Render("✓ Green checkmark: Identical files (same content)"))
 	b.WriteString("\n")
 	b.WriteString(differentStyle.Render("✗ Red X: Different files (content differs)"))
 	b.WriteString("\n")
+	b.WriteString(sizeStyle.Render("≈ Gray: Same text in different encodings"))
+	b.WriteString("\n")
+	b.WriteString(sizeStyle.Render("+A −R ~M (N% similar): Lines added, removed and modified in different files"))
+	b.WriteString("\n")
 	b.WriteString(leftOnlyStyle.Render("◄ Blue arrow: File exists only in LEFT directory"))
 	b.WriteString("\n")
 	b.WriteString(rightOnlyStyle.Render("► Orange arrow: File exists only in RIGHT directory"))
@@ -733,22 +889,26 @@ func (m *Model) renderMergeView() string {
 	if m.changeSelection != nil {
 		selIns, totIns, selDel, totDel := m.changeSelection.GetSelectedStats(m.currentDiff)
 		stats := fmt.Sprintf("Selected: %d/%d insertions, %d/%d deletions", selIns, totIns, selDel, totDel)
+		if k := differ.HunkAt(m.hunks, m.cursor); k >= 0 {
+			stats += fmt.Sprintf(" • Hunk %d/%d", k+1, len(m.hunks))
+		}
 		b.WriteString(helpStyle.Width(m.windowWidth).Render(stats))
 		b.WriteString("\n\n")
 	}
 
 	// Diff content with selection indicators
 	b.WriteString(m.renderMergeContent())
+	b.WriteString(m.renderStatus())
 
 	// Help text
 	b.WriteString("\n")
 	var helpText string
 	if m.windowWidth > 80 {
-		helpText = "Space/Enter: Toggle • t: Switch target • a: Select all • n: Select none • s: Save • Esc: Back • ?: Help"
+		helpText = "Space/Enter: Toggle • h: Toggle hunk • [/]: Prev/Next hunk • t: Switch target • a: Select all • n: Select none • s: Save • Esc: Back • ?: Help"
 	} else if m.windowWidth > 60 {
-		helpText = "Space: Toggle • t: Target • a: All • n: None • s: Save • Esc: Back"
+		helpText = "Space: Toggle • h: Hunk • t: Target • a: All • n: None • s: Save • Esc: Back"
 	} else {
-		helpText = "Space:Toggle t:Target a:All n:None s:Save Esc:Back"
+		helpText = "Space:Toggle h:Hunk t:Target a:All n:None s:Save Esc:Back"
 	}
 	b.WriteString(helpStyle.Width(m.windowWidth).Render(helpText))
 
@@ -793,6 +953,7 @@ func (m *Model) renderCopyView() string {
 
 	// Copy content with selection indicators
 	b.WriteString(m.renderCopyContent())
+	b.WriteString(m.renderStatus())
 
 	// Help text
 	b.WriteString("\n")
@@ -944,6 +1105,7 @@ func (m *Model) renderSideBySideContent() string {
 		end = len(m.sbsRows)
 	}
 
+	showEOL := m.currentDiff.EOLDiffers()
 	for i := start; i < end; i++ {
 		row := m.sbsRows[i]
 
@@ -984,20 +1146,58 @@ func (m *Model) renderSideBySideContent() string {
 			rc = rc[:maxContentWidth-3] + "..."
 		}
 
+		// Show line endings wherever they take part in a difference
+		leftMarker, rightMarker := "", ""
+		if (showEOL && row.Type != differ.SBSEqual) || row.Equalized&differ.EqualizedEOL != 0 {
+			if row.LeftLineNum > 0 {
+				leftMarker = eolMarker(row.LeftEOL)
+			}
+			if row.RightLineNum > 0 {
+				rightMarker = eolMarker(row.RightEOL)
+			}
+		}
+		lc += leftMarker
+		rc += rightMarker
+
 		var leftSide, rightSide string
-		switch row.Type {
-		case differ.SBSEqual:
+		switch {
+		case row.Type == differ.SBSEqual && row.Equalized != 0:
+			leftSide = renderEqualizedLine(cursorStr+leftNumStr, lc, row.Equalized, sideWidth)
+			rightSide = renderEqualizedLine("  "+rightNumStr, rc, row.Equalized, sideWidth)
+		case row.Type == differ.SBSEqual:
 			leftSide = equalLineStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s %s", cursorStr, leftNumStr, lc))
 			rightSide = equalLineStyle.Width(sideWidth).Render(fmt.Sprintf("  %s %s", rightNumStr, rc))
-		case differ.SBSDelete:
-			leftSide = deleteLineStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s %s", cursorStr, leftNumStr, lc))
+		case row.Ignored && row.Type == differ.SBSDelete:
+			leftSide = ignoredLineStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s %s", cursorStr, leftNumStr, lc))
 			rightSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("  %s", rightNumStr))
-		case differ.SBSInsert:
+		case row.Ignored && row.Type == differ.SBSInsert:
 			leftSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s", cursorStr, leftNumStr))
-			rightSide = insertLineStyle.Width(sideWidth).Render(fmt.Sprintf("  %s %s", rightNumStr, rc))
-		case differ.SBSModified:
+			rightSide = ignoredLineStyle.Width(sideWidth).Render(fmt.Sprintf("  %s %s", rightNumStr, rc))
+		case row.MoveID != 0 && row.Type == differ.SBSDelete:
+			leftSide = movedFromStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s %s", cursorStr, leftNumStr, lc))
+			rightSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("  %s", rightNumStr))
+		case row.MoveID != 0 && row.Type == differ.SBSInsert:
+			leftSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s", cursorStr, leftNumStr))
+			rightSide = movedToStyle.Width(sideWidth).Render(fmt.Sprintf("  %s %s", rightNumStr, rc))
+		case row.Type == differ.SBSDelete && row.LeftSpans != nil:
+			leftSide = renderChangedLine(fmt.Sprintf("%s%s ", cursorStr, leftNumStr), row.LeftContent+leftMarker, row.LeftSpans,
+				m.hScrollOffset, maxContentWidth, sideWidth, modifiedDeleteStyle, deleteLineStyle)
+			rightSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("  %s", rightNumStr))
+		case row.Type == differ.SBSInsert && row.RightSpans != nil:
+			leftSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s", cursorStr, leftNumStr))
+			rightSide = renderChangedLine(fmt.Sprintf("  %s ", rightNumStr), row.RightContent+rightMarker, row.RightSpans,
+				m.hScrollOffset, maxContentWidth, sideWidth, modifiedInsertStyle, insertLineStyle)
+		case row.Type == differ.SBSDelete:
 			leftSide = deleteLineStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s %s", cursorStr, leftNumStr, lc))
+			rightSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("  %s", rightNumStr))
+		case row.Type == differ.SBSInsert:
+			leftSide = emptyDiffStyle.Width(sideWidth).Render(fmt.Sprintf("%s%s", cursorStr, leftNumStr))
 			rightSide = insertLineStyle.Width(sideWidth).Render(fmt.Sprintf("  %s %s", rightNumStr, rc))
+		case row.Type == differ.SBSModified:
+			leftSide = renderChangedLine(fmt.Sprintf("%s%s ", cursorStr, leftNumStr), row.LeftContent+leftMarker, row.LeftSpans,
+				m.hScrollOffset, maxContentWidth, sideWidth, modifiedDeleteStyle, deleteLineStyle)
+			rightSide = renderChangedLine(fmt.Sprintf("  %s ", rightNumStr), row.RightContent+rightMarker, row.RightSpans,
+				m.hScrollOffset, maxContentWidth, sideWidth, modifiedInsertStyle, insertLineStyle)
 		}
 
 		b.WriteString(leftSide + " │ " + rightSide)
@@ -1018,6 +1218,153 @@ func (m *Model) renderSideBySideContent() string {
 	return b.String()
 }
 
+// eolMarker returns the visible marker for a line ending
+func eolMarker(e differ.LineEnding) string {
+	switch e {
+	case differ.EOLCRLF:
+		return "␍␊"
+	case differ.EOLLF:
+		return "␊"
+	default:
+		return "⌀"
+	}
+}
+
+// eolLabel describes a file's line endings for the header
+func eolLabel(e differ.LineEnding, noFinalNewline bool) string {
+	if noFinalNewline {
+		return e.String() + ", no final newline"
+	}
+	return e.String()
+}
+
+// encodingLabel names the encodings of a pair when they are not both plain
+// UTF-8: one name if they agree, "left → right" if not
+func encodingLabel(fc *file.FileComparison) string {
+	if fc == nil || fc.LeftFile == nil || fc.RightFile == nil {
+		return ""
+	}
+	left, right := fc.LeftFile.Encoding, fc.RightFile.Encoding
+	switch {
+	case left != right:
+		return left.String() + " → " + right.String()
+	case !left.IsUTF8():
+		return left.String()
+	}
+	return ""
+}
+
+// renderStatus renders the outcome of the last background action, if any
+func (m *Model) renderStatus() string {
+	if m.statusMsg == "" {
+		return ""
+	}
+	return "\n" + statusStyle.Width(m.windowWidth).Render(m.statusMsg)
+}
+
+// equalizedSummary counts the equal lines that only matched under each
+// relaxed comparison, as "≈ 3 case, 1 unicode"; "" when there are none
+func equalizedSummary(lines []differ.DiffLine) string {
+	var all differ.Equalization
+	counts := make(map[differ.Equalization]int)
+	for _, line := range lines {
+		if line.Type != differ.DiffEqual {
+			continue
+		}
+		all |= line.Equalized
+		for _, flag := range line.Equalized.Flags() {
+			counts[flag]++
+		}
+	}
+	var parts []string
+	for _, flag := range all.Flags() {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[flag], flag))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "≈ " + strings.Join(parts, ", ")
+}
+
+// renderEqualizedLine renders an equal line whose sides only matched under
+// a relaxed comparison. A marker replaces the gap after the line number so
+// the difference is never silently hidden: ≡ for lines matched by an ignore
+// rule, ≈ for other relaxed comparisons such as whitespace or case.
+func renderEqualizedLine(gutter, content string, eq differ.Equalization, width int) string {
+	marker := "≈"
+	if eq&differ.EqualizedRule != 0 {
+		marker = "≡"
+	}
+	base := unsized(equalLineStyle)
+	line := base.Render(gutter) + equalizedMarkerStyle.Render(marker) + base.Render(content)
+	if pad := width - displayWidth(line); pad > 0 {
+		line += base.Render(strings.Repeat(" ", pad))
+	}
+	return line
+}
+
+// renderChangedLine renders one side of a modified line: the changed spans
+// are drawn in hi and the rest of the line in base. The content is shifted
+// by offset bytes and truncated to maxContent bytes the same way the plain
+// renderers do, and the result is padded to width columns.
+func renderChangedLine(prefix, content string, spans []differ.Span, offset, maxContent, width int, base, hi lipgloss.Style) string {
+	start := offset
+	if start > len(content) {
+		start = len(content)
+	}
+	text := content[start:]
+	ellipsis := ""
+	if len(text) > maxContent {
+		text = text[:maxContent-3]
+		ellipsis = "..."
+	}
+
+	base, hi = unsized(base), unsized(hi)
+	var b strings.Builder
+	b.WriteString(base.Render(prefix))
+	pos := 0
+	for _, span := range spans {
+		s, e := span.Start-start, span.End-start
+		if s < pos {
+			s = pos
+		}
+		if e > len(text) {
+			e = len(text)
+		}
+		if e <= s {
+			continue
+		}
+		if s > pos {
+			b.WriteString(base.Render(text[pos:s]))
+		}
+		b.WriteString(hi.Render(text[s:e]))
+		pos = e
+	}
+	if pos < len(text) {
+		b.WriteString(base.Render(text[pos:]))
+	}
+	if ellipsis != "" {
+		b.WriteString(base.Render(ellipsis))
+	}
+
+	if pad := width - displayWidth(b.String()); pad > 0 {
+		b.WriteString(base.Render(strings.Repeat(" ", pad)))
+	}
+	return b.String()
+}
+
+// unsized returns a copy of style without a width. Styles share their rules
+// between copies, so a Width set elsewhere would otherwise pad every piece.
+func unsized(style lipgloss.Style) lipgloss.Style {
+	return style.Copy().UnsetWidth()
+}
+
+// displayWidth measures rendered text the way lipgloss lays it out, with
+// tabs expanded to four columns
+func displayWidth(s string) int {
+	return lipgloss.Width(strings.ReplaceAll(s, "\t", "    "))
+}
+
 func (m *Model) renderMergeContent() string {
 	if m.currentDiff == nil || len(m.currentDiff.Lines) == 0 {
 		return "No differences found"
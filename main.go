@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"golang-fileCmp/internal/differ"
 	"golang-fileCmp/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,7 +19,23 @@ func main() {
 	model := ui.New()
 
 	// Handle command line arguments
-	args := os.Args[1:]
+	opts, args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	model.SetDiffOptions(opts.diff)
+	model.SetJSONOptions(opts.json)
+	model.SetYAMLOptions(opts.yaml)
+	model.SetTableOptions(opts.table)
+	model.SetKeyValueOptions(opts.kv)
+	for _, a := range opts.comparators {
+		if err := model.Comparators().Assign(a[0], a[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --comparator: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	model.SetContextLines(opts.context)
 
 	// Show usage if help is requested (must check before SetLeftPath)
 	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
@@ -55,17 +75,228 @@ func main() {
 	}
 }
 
+// cliOptions holds the settings given as command-line flags
+type cliOptions struct {
+	diff    differ.Options
+	json    differ.JSONOptions
+	yaml    differ.YAMLOptions
+	table   differ.TableOptions
+	kv      differ.KeyValueOptions
+	context int // context lines for hunks and exported patches
+
+	// comparators pairs file patterns with the comparator chosen for them
+	comparators [][2]string
+}
+
+// parseArgs pulls option flags out of args and returns the remaining
+// positional arguments. Flags may appear anywhere and accept either
+// "--flag value" or "--flag=value".
+func parseArgs(args []string) (cliOptions, []string, error) {
+	opts := cliOptions{
+		diff:    ui.DefaultDiffOptions(),
+		json:    differ.DefaultJSONOptions(),
+		yaml:    differ.DefaultYAMLOptions(),
+		context: differ.DefaultContext,
+	}
+	var positional []string
+	arrayKeysSet := false // the first --array-key replaces the defaults
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+
+		// flagValue returns the flag's argument, consuming the next arg if needed
+		flagValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch name {
+		case "--diff-algorithm":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			alg, err := differ.ParseAlgorithm(v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.diff.Algorithm = alg
+		case "--granularity":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			g, err := differ.ParseGranularity(v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.diff.Granularity = g
+		case "--ignore-trailing-whitespace":
+			opts.diff.Whitespace = differ.WhitespaceIgnoreTrailing
+		case "--ignore-whitespace-amount":
+			opts.diff.Whitespace = differ.WhitespaceIgnoreAmount
+		case "--ignore-all-whitespace":
+			opts.diff.Whitespace = differ.WhitespaceIgnoreAll
+		case "--ignore-case":
+			opts.diff.FoldCase = true
+		case "--normalize":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			n, err := differ.ParseNormalization(v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.diff.Normalization = n
+		case "--ignore-blank-lines":
+			opts.diff.IgnoreBlankLines = true
+		case "--ignore-eol":
+			opts.diff.IgnoreEOL = true
+		case "--no-moves":
+			opts.diff.DetectMoves = false
+		case "--ignore-regex", "--ignore-line-regex":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			kind := differ.IgnoreMask
+			if name == "--ignore-line-regex" {
+				kind = differ.IgnoreLine
+			}
+			rule, err := differ.NewIgnoreRule(kind, v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.diff.IgnoreRules = append(opts.diff.IgnoreRules, rule)
+		case "--ignore-file":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			rules, err := differ.LoadIgnoreRules(v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.diff.IgnoreRules = append(opts.diff.IgnoreRules, rules...)
+		case "--context", "-U":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return opts, nil, fmt.Errorf("%s wants a non-negative number of lines, got %q", name, v)
+			}
+			opts.context = n
+		case "--time-budget":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return opts, nil, fmt.Errorf("%s wants a duration such as 500ms or 5s, got %q", name, v)
+			}
+			opts.diff.TimeBudget = d
+		case "--array-key":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			if !arrayKeysSet {
+				opts.json.ArrayKeys, opts.yaml.ListKeys, arrayKeysSet = nil, nil, true
+			}
+			opts.json.ArrayKeys = append(opts.json.ArrayKeys, v)
+			opts.yaml.ListKeys = append(opts.yaml.ListKeys, v)
+		case "--key-column":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.table.KeyColumns = append(opts.table.KeyColumns, v)
+		case "--mask-secrets":
+			opts.kv.MaskSecrets = true
+		case "--comparator":
+			v, err := flagValue()
+			if err != nil {
+				return opts, nil, err
+			}
+			pattern, comparator, ok := strings.Cut(v, "=")
+			if !ok || pattern == "" || comparator == "" {
+				return opts, nil, fmt.Errorf("%s wants PATTERN=NAME, such as '*.conf=ini', got %q", name, v)
+			}
+			opts.comparators = append(opts.comparators, [2]string{pattern, comparator})
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+
+	return opts, positional, nil
+}
+
 func showUsage() {
 	fmt.Printf(`File Comparison TUI Tool
 
 Usage:
-  %s [left_path] [right_path]
-  %s --git [left_ref] [right_ref]
+  %s [options] [left_path] [right_path]
+  %s [options] --git [left_ref] [right_ref]
 
 Arguments:
   left_path   Path to left file or directory (optional)
   right_path  Path to right file or directory (optional)
 
+Options:
+  --diff-algorithm <name>   Line matching algorithm: myers (default),
+                            patience or histogram
+  --granularity <name>      Intra-line highlighting: word (default), char,
+                            or token to compare source code tokens across
+                            the lines of each change
+  --ignore-trailing-whitespace
+                            Ignore whitespace at the end of lines
+  --ignore-whitespace-amount
+                            Treat runs of whitespace as a single space
+  --ignore-all-whitespace   Ignore all whitespace when comparing lines
+  --ignore-case             Compare lines without regard to letter case
+  --normalize <form>        Compare lines in Unicode normal form nfc or
+                            nfkc, so composed and decomposed accents match
+  --ignore-blank-lines      Don't count added or removed blank lines
+  --ignore-eol              Ignore CRLF/LF and missing final newline
+                            differences
+  --no-moves                Don't detect moved blocks
+  --ignore-regex RE         Mask text matching RE before comparing lines
+                            (repeatable)
+  --ignore-line-regex RE    Treat any two lines matching RE as equal
+                            (repeatable)
+  --ignore-file FILE        Load rules from FILE, one per line:
+                            "mask <regex>" or "line <regex>", # comments
+  -U, --context N           Context lines around hunks and in exported
+                            patches (default 3)
+  --time-budget DURATION    Time to spend finding a minimal diff before
+                            settling for an approximate one (default 2s,
+                            0 for no limit)
+  --array-key KEY           Match JSON array and YAML list elements by
+                            their KEY field rather than by position
+                            (repeatable; default id, name and key)
+  --key-column NAME         Match CSV/TSV rows on column NAME; repeat for
+                            a compound key (default: the first column)
+  --mask-secrets            Hide passwords, tokens and other secret-looking
+                            values when comparing INI, .env and properties
+                            files by key. Only the structural summary and
+                            its .changes.txt export are masked; the line
+                            diff and .patch exports show the values as is
+  --comparator PATTERN=NAME Compare files matching PATTERN (an extension
+                            such as .conf or a glob such as 'Dockerfile*')
+                            with comparator NAME: json, yaml, csv, tsv,
+                            ini, env, properties, xml, html, markdown, go,
+                            or lines for the line diff only (repeatable)
+
 Git Mode:
   --git                     Compare HEAD against working tree
   --git <ref>               Compare <ref> against working tree
@@ -78,6 +309,7 @@ Examples:
   %s --git                     # HEAD vs working tree
   %s --git HEAD~1              # Previous commit vs working tree
   %s --git HEAD~3 HEAD         # Three commits ago vs current HEAD
+  %s --diff-algorithm patience a.go b.go
 
 Interactive Controls:
   Tab              Switch between input fields / Navigate suggestions
@@ -87,19 +319,43 @@ Interactive Controls:
   Esc              Clear suggestions / filter
   Ctrl+D           Start diff comparison
   s                Switch view (Unified / Side-by-Side)
+  a                Cycle diff algorithm (Myers / Patience / Histogram)
+  w                Cycle intra-line highlighting (word / character / token)
+  i                Cycle whitespace handling (exact/trailing/amount/all)
+  C                Toggle ignoring letter case
+  u                Cycle Unicode normalization (none/NFC/NFKC)
+  b                Toggle ignoring blank lines
+  r                Toggle ignoring line endings
   h/l or ←/→       Horizontal scroll in side-by-side view
   j/k              Navigate diff (vim-style)
+  ]/[              Jump to next/previous hunk, or difference in the hex
+                   view of binary files
+  v                Switch between the structural summary (JSON, YAML,
+                   CSV/TSV table, INI/.env/properties keys, XML/HTML
+                   elements, Markdown sections, Go declarations) and the
+                   line diff
+  Enter            In the structural summary, show the change's lines
+  f                Jump between a moved block's source and destination
+  e                Export the current diff as <file>.patch, or the
+                   structural summary as <file>.changes.txt
   n/p              Next/previous file
   g/G              Go to top/bottom
-  m                Enter merge mode
-  Esc              Go back
+  m                Enter merge mode (h toggles the current hunk)
+  Esc              Cancel a running diff / Go back
   ?                Show help
   Q/Ctrl+C         Quit
 
 Colors:
   Blue background:  Added lines (+)
   Red background:   Deleted lines (-)
+  Dark background:  Unchanged part of a modified line; the changed
+                    words, characters or tokens keep the bright colour
+  Purple / teal:    Block moved away (<) / moved here (>)
   Gray text:        Unchanged lines
+  ≈ marker:         Lines equal only after ignoring differences in
+                    whitespace, case, Unicode form or line endings
+  ≡ marker:         Lines equal because of an ignore rule
+  ␍␊ / ␊ / ⌀:       CRLF, LF or no final newline (when endings differ)
 
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 }